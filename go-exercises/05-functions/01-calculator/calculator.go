@@ -1,110 +1,106 @@
 package main
 
 import (
-    "errors"
-    "fmt"
     "math"
+    "sync"
+    "time"
 )
 
-// Calculator represents a calculator with basic arithmetic operations
+// Calculator represents a calculator with basic arithmetic operations.
+// It is safe for concurrent use by multiple goroutines.
 type Calculator struct {
-    history []string
+    mu        sync.RWMutex // guards history and angleMode
+    history   []HistoryEntry
+    now       func() time.Time
+    registry  *Registry
+    angleMode AngleMode
+    units     *UnitRegistry
 }
 
 // NewCalculator creates a new calculator instance
 func NewCalculator() *Calculator {
-    return &Calculator{
-        history: make([]string, 0),
-    }
+    c := &Calculator{
+        history:  make([]HistoryEntry, 0),
+        now:      time.Now,
+        registry: newBuiltinRegistry(),
+        units:    NewUnitRegistry(),
+    }
+    c.registerScientific()
+    return c
 }
 
 // Add adds two numbers
 func (c *Calculator) Add(a, b float64) (float64, error) {
-    if math.IsNaN(a) || math.IsNaN(b) {
-        return 0, errors.New("invalid input: NaN values")
-    }
-    if math.IsInf(a, 0) || math.IsInf(b, 0) {
-        return 0, errors.New("invalid input: infinite values")
-    }
-    result := a + b
-    if math.IsInf(result, 0) {
-        return 0, errors.New("result overflow")
-    }
-    c.history = append(c.history, fmt.Sprintf("%f + %f = %f", a, b, result))
-    return result, nil
+    return c.Apply("add", a, b)
 }
 
 // Subtract subtracts two numbers
 func (c *Calculator) Subtract(a, b float64) (float64, error) {
-    if math.IsNaN(a) || math.IsNaN(b) {
-        return 0, errors.New("invalid input: NaN values")
-    }
-    if math.IsInf(a, 0) || math.IsInf(b, 0) {
-        return 0, errors.New("invalid input: infinite values")
-    }
-    result := a - b
-    if math.IsInf(result, 0) {
-        return 0, errors.New("result overflow")
-    }
-    c.history = append(c.history, fmt.Sprintf("%f - %f = %f", a, b, result))
-    return result, nil
+    return c.Apply("subtract", a, b)
 }
 
 // Multiply multiplies two numbers
 func (c *Calculator) Multiply(a, b float64) (float64, error) {
-    if math.IsNaN(a) || math.IsNaN(b) {
-        return 0, errors.New("invalid input: NaN values")
-    }
-    if math.IsInf(a, 0) || math.IsInf(b, 0) {
-        return 0, errors.New("invalid input: infinite values")
-    }
-    result := a * b
-    if math.IsInf(result, 0) {
-        return 0, errors.New("result overflow")
-    }
-    c.history = append(c.history, fmt.Sprintf("%f * %f = %f", a, b, result))
-    return result, nil
+    return c.Apply("multiply", a, b)
 }
 
 // Divide divides two numbers
 func (c *Calculator) Divide(a, b float64) (float64, error) {
-    if math.IsNaN(a) || math.IsNaN(b) {
-        return 0, errors.New("invalid input: NaN values")
-    }
-    if math.IsInf(a, 0) || math.IsInf(b, 0) {
-        return 0, errors.New("invalid input: infinite values")
-    }
-    if b == 0 {
-        return 0, errors.New("division by zero")
-    }
-    result := a / b
-    if math.IsInf(result, 0) {
-        return 0, errors.New("result overflow")
-    }
-    c.history = append(c.history, fmt.Sprintf("%f / %f = %f", a, b, result))
-    return result, nil
+    return c.Apply("divide", a, b)
 }
 
 // Power calculates a raised to the power of b
 func (c *Calculator) Power(base, exponent float64) (float64, error) {
-    if math.IsNaN(base) || math.IsNaN(exponent) {
-        return 0, errors.New("invalid input: NaN values")
-    }
-    if math.IsInf(base, 0) || math.IsInf(exponent, 0) {
-        return 0, errors.New("invalid input: infinite values")
-    }
-    if base < 0 && !math.IsInf(exponent, 0) && exponent != float64(int64(exponent)) {
-        return 0, errors.New("invalid operation: negative base with non-integer exponent")
-    }
-    result := math.Pow(base, exponent)
-    if math.IsInf(result, 0) {
-        return 0, errors.New("result overflow")
-    }
-    c.history = append(c.history, fmt.Sprintf("%f ^ %f = %f", base, exponent, result))
-    return result, nil
+    return c.Apply("power", base, exponent)
 }
 
-// GetHistory returns the operation history
+// GetHistory returns the successful operations formatted as "a + b = result"
 func (c *Calculator) GetHistory() []string {
-    return c.history
-} 
\ No newline at end of file
+    return c.History().Successful().Strings()
+}
+
+// newBuiltinRegistry registers the five arithmetic operators every Calculator starts with;
+// NewCalculator adds the scientific functions on top
+func newBuiltinRegistry() *Registry {
+    r := NewRegistry()
+    builtins := []Operator{
+        {Name: "add", Symbol: "+", Arity: 2, Policy: ValidateAll, Fn: add},
+        {Name: "subtract", Symbol: "-", Arity: 2, Policy: ValidateAll, Fn: subtract},
+        {Name: "multiply", Symbol: "*", Arity: 2, Policy: ValidateAll, Fn: multiply},
+        {Name: "divide", Symbol: "/", Arity: 2, Policy: ValidateAll, Fn: divide},
+        {Name: "power", Symbol: "^", Arity: 2, Policy: ValidateAll, Fn: power},
+    }
+    for _, op := range builtins {
+        if err := r.Register(op); err != nil {
+            panic(err)
+        }
+    }
+    return r
+}
+
+func add(args ...float64) (float64, error) {
+    return args[0] + args[1], nil
+}
+
+func subtract(args ...float64) (float64, error) {
+    return args[0] - args[1], nil
+}
+
+func multiply(args ...float64) (float64, error) {
+    return args[0] * args[1], nil
+}
+
+func divide(args ...float64) (float64, error) {
+    if args[1] == 0 {
+        return 0, ErrDivisionByZero
+    }
+    return args[0] / args[1], nil
+}
+
+func power(args ...float64) (float64, error) {
+    base, exponent := args[0], args[1]
+    if base < 0 && exponent != math.Trunc(exponent) {
+        return 0, ErrNegativeBase
+    }
+    return math.Pow(base, exponent), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+    "errors"
     "math"
     "testing"
 )
@@ -21,22 +22,22 @@ func TestAdd(t *testing.T) {
         name     string
         a, b     float64
         expected float64
-        wantErr  bool
+        wantErr  error
     }{
-        {"basic addition", 2, 3, 5, false},
-        {"negative numbers", -2, -3, -5, false},
-        {"zero", 0, 0, 0, false},
-        {"large numbers", 1e308, 1e308, 0, true}, // overflow
-        {"NaN", math.NaN(), 1, 0, true},
-        {"Inf", math.Inf(1), 1, 0, true},
+        {"basic addition", 2, 3, 5, nil},
+        {"negative numbers", -2, -3, -5, nil},
+        {"zero", 0, 0, 0, nil},
+        {"large numbers", 1e308, 1e308, 0, ErrOverflow}, // overflow
+        {"NaN", math.NaN(), 1, 0, ErrNaN},
+        {"Inf", math.Inf(1), 1, 0, ErrInfinite},
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             result, err := calc.Add(tt.a, tt.b)
-            if tt.wantErr {
-                if err == nil {
-                    t.Error("expected error but got none")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
                 }
                 return
             }
@@ -57,22 +58,22 @@ func TestSubtract(t *testing.T) {
         name     string
         a, b     float64
         expected float64
-        wantErr  bool
+        wantErr  error
     }{
-        {"basic subtraction", 5, 3, 2, false},
-        {"negative result", 3, 5, -2, false},
-        {"zero", 0, 0, 0, false},
-        {"large numbers", -1e308, 1e308, 0, true}, // overflow
-        {"NaN", math.NaN(), 1, 0, true},
-        {"Inf", math.Inf(1), 1, 0, true},
+        {"basic subtraction", 5, 3, 2, nil},
+        {"negative result", 3, 5, -2, nil},
+        {"zero", 0, 0, 0, nil},
+        {"large numbers", -1e308, 1e308, 0, ErrOverflow}, // overflow
+        {"NaN", math.NaN(), 1, 0, ErrNaN},
+        {"Inf", math.Inf(1), 1, 0, ErrInfinite},
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             result, err := calc.Subtract(tt.a, tt.b)
-            if tt.wantErr {
-                if err == nil {
-                    t.Error("expected error but got none")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
                 }
                 return
             }
@@ -93,22 +94,22 @@ func TestMultiply(t *testing.T) {
         name     string
         a, b     float64
         expected float64
-        wantErr  bool
+        wantErr  error
     }{
-        {"basic multiplication", 2, 3, 6, false},
-        {"negative numbers", -2, -3, 6, false},
-        {"zero", 0, 5, 0, false},
-        {"large numbers", 1e154, 1e154, 0, true}, // overflow
-        {"NaN", math.NaN(), 1, 0, true},
-        {"Inf", math.Inf(1), 1, 0, true},
+        {"basic multiplication", 2, 3, 6, nil},
+        {"negative numbers", -2, -3, 6, nil},
+        {"zero", 0, 5, 0, nil},
+        {"large numbers", 1e155, 1e155, 0, ErrOverflow}, // overflow
+        {"NaN", math.NaN(), 1, 0, ErrNaN},
+        {"Inf", math.Inf(1), 1, 0, ErrInfinite},
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             result, err := calc.Multiply(tt.a, tt.b)
-            if tt.wantErr {
-                if err == nil {
-                    t.Error("expected error but got none")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
                 }
                 return
             }
@@ -129,22 +130,22 @@ func TestDivide(t *testing.T) {
         name     string
         a, b     float64
         expected float64
-        wantErr  bool
+        wantErr  error
     }{
-        {"basic division", 6, 2, 3, false},
-        {"fraction result", 5, 2, 2.5, false},
-        {"zero numerator", 0, 5, 0, false},
-        {"zero denominator", 5, 0, 0, true},
-        {"NaN", math.NaN(), 1, 0, true},
-        {"Inf", math.Inf(1), 1, 0, true},
+        {"basic division", 6, 2, 3, nil},
+        {"fraction result", 5, 2, 2.5, nil},
+        {"zero numerator", 0, 5, 0, nil},
+        {"zero denominator", 5, 0, 0, ErrDivisionByZero},
+        {"NaN", math.NaN(), 1, 0, ErrNaN},
+        {"Inf", math.Inf(1), 1, 0, ErrInfinite},
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             result, err := calc.Divide(tt.a, tt.b)
-            if tt.wantErr {
-                if err == nil {
-                    t.Error("expected error but got none")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
                 }
                 return
             }
@@ -165,22 +166,22 @@ func TestPower(t *testing.T) {
         name     string
         base, exp float64
         expected float64
-        wantErr  bool
+        wantErr  error
     }{
-        {"basic power", 2, 3, 8, false},
-        {"negative exponent", 2, -1, 0.5, false},
-        {"zero exponent", 5, 0, 1, false},
-        {"negative base with non-integer exponent", -2, 1.5, 0, true},
-        {"NaN", math.NaN(), 1, 0, true},
-        {"Inf", math.Inf(1), 1, 0, true},
+        {"basic power", 2, 3, 8, nil},
+        {"negative exponent", 2, -1, 0.5, nil},
+        {"zero exponent", 5, 0, 1, nil},
+        {"negative base with non-integer exponent", -2, 1.5, 0, ErrNegativeBase},
+        {"NaN", math.NaN(), 1, 0, ErrNaN},
+        {"Inf", math.Inf(1), 1, 0, ErrInfinite},
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             result, err := calc.Power(tt.base, tt.exp)
-            if tt.wantErr {
-                if err == nil {
-                    t.Error("expected error but got none")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
                 }
                 return
             }
@@ -0,0 +1,226 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "math/cmplx"
+    "strconv"
+    "strings"
+)
+
+// ComplexCalculator offers the Calculator arithmetic on complex128 values, so that
+// Power(-8, 1.0/3) returns the principal root instead of ErrNegativeBase.
+// Operations are recorded in the parent Calculator's history as "a+bi"; the
+// entries' Operands and Result hold the real parts. Phase, Polar and Rect use
+// the parent's angle mode.
+type ComplexCalculator struct {
+    calc *Calculator
+}
+
+// Complex returns a complex-number view of the calculator
+func (c *Calculator) Complex() *ComplexCalculator {
+    return &ComplexCalculator{calc: c}
+}
+
+// Add adds two complex numbers
+func (cc *ComplexCalculator) Add(a, b complex128) (complex128, error) {
+    return cc.apply("add", "+", []complex128{a, b}, func() (complex128, error) {
+        return a + b, nil
+    })
+}
+
+// Subtract subtracts b from a
+func (cc *ComplexCalculator) Subtract(a, b complex128) (complex128, error) {
+    return cc.apply("subtract", "-", []complex128{a, b}, func() (complex128, error) {
+        return a - b, nil
+    })
+}
+
+// Multiply multiplies two complex numbers
+func (cc *ComplexCalculator) Multiply(a, b complex128) (complex128, error) {
+    return cc.apply("multiply", "*", []complex128{a, b}, func() (complex128, error) {
+        return a * b, nil
+    })
+}
+
+// Divide divides a by b
+func (cc *ComplexCalculator) Divide(a, b complex128) (complex128, error) {
+    return cc.apply("divide", "/", []complex128{a, b}, func() (complex128, error) {
+        if b == 0 {
+            return 0, ErrDivisionByZero
+        }
+        return a / b, nil
+    })
+}
+
+// Power returns the principal value of base raised to exponent
+func (cc *ComplexCalculator) Power(base, exponent complex128) (complex128, error) {
+    return cc.apply("power", "^", []complex128{base, exponent}, func() (complex128, error) {
+        if base == 0 && real(exponent) < 0 {
+            return 0, ErrDivisionByZero
+        }
+        if exponent == complex(math.Round(real(exponent)), 0) && math.Abs(real(exponent)) <= 64 {
+            // repeated multiplication keeps small integer powers exact, e.g. i^2 = -1
+            return integerPower(base, int(real(exponent))), nil
+        }
+        return cmplx.Pow(base, exponent), nil
+    })
+}
+
+// Sqrt returns the principal square root of z
+func (cc *ComplexCalculator) Sqrt(z complex128) (complex128, error) {
+    return cc.apply("sqrt", "sqrt", []complex128{z}, func() (complex128, error) {
+        return cmplx.Sqrt(z), nil
+    })
+}
+
+// Conj returns the complex conjugate of z
+func (cc *ComplexCalculator) Conj(z complex128) (complex128, error) {
+    return cc.apply("conj", "conj", []complex128{z}, func() (complex128, error) {
+        return cmplx.Conj(z), nil
+    })
+}
+
+// Abs returns the magnitude of z
+func (cc *ComplexCalculator) Abs(z complex128) (float64, error) {
+    return cc.applyReal("abs", z, func() float64 {
+        return cmplx.Abs(z)
+    })
+}
+
+// Phase returns the argument of z in (-π, π], or (-180, 180] in degree mode
+func (cc *ComplexCalculator) Phase(z complex128) (float64, error) {
+    mode := cc.calc.AngleMode()
+    return cc.applyReal("phase", z, func() float64 {
+        return fromRadians(mode, cmplx.Phase(z))
+    })
+}
+
+// Polar returns the magnitude and phase of z
+func (cc *ComplexCalculator) Polar(z complex128) (r, theta float64, err error) {
+    mode := cc.calc.AngleMode()
+    entry, _, err := cc.entry("polar", []complex128{z}, func() (complex128, string, error) {
+        r, theta = cmplx.Polar(z)
+        theta = fromRadians(mode, theta)
+        return complex(r, 0), fmt.Sprintf("(%s, %s)", formatFloat(r), formatFloat(theta)), nil
+    })
+    cc.calc.record(entry)
+    if err != nil {
+        return 0, 0, err
+    }
+    return r, theta, nil
+}
+
+// Rect returns the complex number with magnitude r and phase theta
+func (cc *ComplexCalculator) Rect(r, theta float64) (complex128, error) {
+    mode := cc.calc.AngleMode()
+    entry, result, err := cc.entry("rect", []complex128{complex(r, 0), complex(theta, 0)}, func() (complex128, string, error) {
+        z := cmplx.Rect(r, toRadians(mode, theta))
+        if turns, ok := quarterTurns(mode, theta); ok {
+            // exact for multiples of 90 degrees, like sin and cos
+            z = complex(r, 0) * [4]complex128{1, 1i, -1, -1i}[turns]
+        }
+        return z, FormatComplex(z), nil
+    })
+    entry.OperandText = []string{formatFloat(r), formatFloat(theta)}
+    cc.calc.record(entry)
+    return result, err
+}
+
+// FormatComplex writes z as "a+bi", e.g. "1-2i" or "0+1i"
+func FormatComplex(z complex128) string {
+    sign := "+"
+    if math.Signbit(imag(z)) {
+        sign = "-"
+    }
+    return formatFloat(real(z)) + sign + formatFloat(math.Abs(imag(z))) + "i"
+}
+
+// ParseComplex reads a complex number such as "3", "2i", "1-2i" or "(1+2i)"
+func ParseComplex(s string) (complex128, error) {
+    text := strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+    z, err := strconv.ParseComplex(text, 128)
+    if err != nil {
+        return 0, fmt.Errorf("%w: invalid complex number %q", ErrSyntax, s)
+    }
+    return z, nil
+}
+
+// apply runs fn on validated operands and records the operation
+func (cc *ComplexCalculator) apply(name, symbol string, args []complex128, fn func() (complex128, error)) (complex128, error) {
+    entry, result, err := cc.entry(name, args, func() (complex128, string, error) {
+        result, err := fn()
+        return result, FormatComplex(result), err
+    })
+    entry.Operator = symbol
+    cc.calc.record(entry)
+    return result, err
+}
+
+// applyReal records a function of z whose result is real
+func (cc *ComplexCalculator) applyReal(name string, z complex128, fn func() float64) (float64, error) {
+    entry, result, err := cc.entry(name, []complex128{z}, func() (complex128, string, error) {
+        x := fn()
+        return complex(x, 0), formatFloat(x), nil
+    })
+    cc.calc.record(entry)
+    return real(result), err
+}
+
+// entry validates the operands, runs fn and builds the history entry; fn returns
+// the result and its text. Failures are wrapped in a *CalcError.
+func (cc *ComplexCalculator) entry(name string, args []complex128, fn func() (complex128, string, error)) (HistoryEntry, complex128, error) {
+    e := HistoryEntry{Operator: name}
+    for _, arg := range args {
+        e.Operands = append(e.Operands, real(arg))
+        e.OperandText = append(e.OperandText, FormatComplex(arg))
+    }
+
+    err := checkComplex(args)
+    var result complex128
+    var text string
+    if err == nil {
+        result, text, err = fn()
+    }
+    if err == nil && (cmplx.IsInf(result) || cmplx.IsNaN(result)) {
+        // finite operands only give NaN parts through an overflowing intermediate, e.g. Inf-Inf
+        err = ErrOverflow
+    }
+    if err != nil {
+        err = &CalcError{Op: name, Operands: e.Operands, OperandText: e.OperandText, Err: err}
+        e.Err = err
+        return e, 0, err
+    }
+    e.Result, e.ResultText = real(result), text
+    return e, result, nil
+}
+
+// checkComplex applies the NaN and infinity checks of the real operators to both parts
+func checkComplex(args []complex128) error {
+    for _, arg := range args {
+        if math.IsNaN(real(arg)) || math.IsNaN(imag(arg)) {
+            return ErrNaN
+        }
+    }
+    for _, arg := range args {
+        if cmplx.IsInf(arg) {
+            return ErrInfinite
+        }
+    }
+    return nil
+}
+
+// integerPower raises z to an integer power by repeated squaring
+func integerPower(z complex128, n int) complex128 {
+    if n < 0 {
+        return 1 / integerPower(z, -n)
+    }
+    result := complex128(1)
+    for ; n > 0; n >>= 1 {
+        if n&1 == 1 {
+            result *= z
+        }
+        z *= z
+    }
+    return result
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "math/cmplx"
+    "testing"
+)
+
+func TestComplexArithmetic(t *testing.T) {
+    calc := NewCalculator().Complex()
+    tests := []struct {
+        name     string
+        op       func(a, b complex128) (complex128, error)
+        a, b     complex128
+        expected complex128
+        wantErr  error
+    }{
+        {"add", calc.Add, 1 + 2i, 3 - 1i, 4 + 1i, nil},
+        {"subtract", calc.Subtract, 1 + 2i, 3 - 1i, -2 + 3i, nil},
+        {"multiply", calc.Multiply, 1 + 2i, 3 - 1i, 5 + 5i, nil},
+        {"i squared", calc.Multiply, 1i, 1i, -1, nil},
+        {"divide", calc.Divide, 5 + 5i, 3 - 1i, 1 + 2i, nil},
+        {"division by zero", calc.Divide, 1 + 1i, 0, 0, ErrDivisionByZero},
+        {"principal cube root of -8", calc.Power, -8, complex(1.0/3, 0), 1 + complex(0, math.Sqrt(3)), nil},
+        {"square root of -4", calc.Power, -4, 0.5, 2i, nil},
+        {"i to the i", calc.Power, 1i, 1i, complex(math.Exp(-math.Pi/2), 0), nil},
+        {"exact integer power", calc.Power, 1i, 2, -1, nil},
+        {"negative integer power", calc.Power, 2i, -2, -0.25, nil},
+        {"zero to a negative power", calc.Power, 0, -1, 0, ErrDivisionByZero},
+        {"power overflows", calc.Power, 1e200 + 1e200i, 2, 0, ErrOverflow},
+        {"NaN operand", calc.Add, complex(math.NaN(), 0), 1, 0, ErrNaN},
+        {"NaN imaginary part", calc.Add, 1, complex(0, math.NaN()), 0, ErrNaN},
+        {"infinite operand", calc.Multiply, complex(0, math.Inf(1)), 1, 0, ErrInfinite},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op(tt.a, tt.b)
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if cmplx.Abs(result-tt.expected) > 1e-12 {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestComplexRealAPIUnchanged(t *testing.T) {
+    calc := NewCalculator()
+    if _, err := calc.Power(-8, 1.0/3); !errors.Is(err, ErrNegativeBase) {
+        t.Errorf("real Power(-8, 1/3) should still fail with ErrNegativeBase, got %v", err)
+    }
+    if _, err := calc.Sqrt(-1); !errors.Is(err, ErrDomain) {
+        t.Errorf("real Sqrt(-1) should still fail with ErrDomain, got %v", err)
+    }
+    root, err := calc.Complex().Sqrt(-1)
+    if err != nil || root != 1i {
+        t.Errorf("complex Sqrt(-1) = %v, %v; want i", root, err)
+    }
+}
+
+func TestComplexPolar(t *testing.T) {
+    calc := NewCalculator()
+    cc := calc.Complex()
+
+    if abs, err := cc.Abs(3 + 4i); err != nil || abs != 5 {
+        t.Errorf("Abs(3+4i) = %v, %v; want 5", abs, err)
+    }
+    if conj, err := cc.Conj(3 + 4i); err != nil || conj != 3-4i {
+        t.Errorf("Conj(3+4i) = %v, %v; want 3-4i", conj, err)
+    }
+    if phase, err := cc.Phase(-1); err != nil || phase != math.Pi {
+        t.Errorf("Phase(-1) = %v, %v; want π", phase, err)
+    }
+
+    r, theta, err := cc.Polar(1 + 1i)
+    if err != nil || math.Abs(r-math.Sqrt2) > 1e-15 || math.Abs(theta-math.Pi/4) > 1e-15 {
+        t.Errorf("Polar(1+i) = %v, %v, %v", r, theta, err)
+    }
+    z, err := cc.Rect(r, theta)
+    if err != nil || cmplx.Abs(z-(1+1i)) > 1e-15 {
+        t.Errorf("Rect(Polar(1+i)) = %v, %v", z, err)
+    }
+
+    calc.SetAngleMode(Degrees)
+    if phase, err := cc.Phase(1i); err != nil || phase != 90 {
+        t.Errorf("Phase(i) in degrees = %v, %v; want 90", phase, err)
+    }
+    if z, err := cc.Rect(2, 90); err != nil || z != 2i {
+        t.Errorf("Rect(2, 90°) = %v, %v; want exactly 2i", z, err)
+    }
+    if _, _, err := cc.Polar(complex(math.Inf(1), 0)); !errors.Is(err, ErrInfinite) {
+        t.Errorf("expected ErrInfinite, got %v", err)
+    }
+}
+
+func TestComplexFormatParse(t *testing.T) {
+    tests := []struct {
+        z    complex128
+        text string
+    }{
+        {1 + 2i, "1+2i"},
+        {1 - 2i, "1-2i"},
+        {-0.5, "-0.5+0i"},
+        {3i, "0+3i"},
+        {complex(1e21, -1e-7), "1e+21-1e-07i"},
+    }
+    for _, tt := range tests {
+        if got := FormatComplex(tt.z); got != tt.text {
+            t.Errorf("FormatComplex(%v) = %q, want %q", tt.z, got, tt.text)
+        }
+        if z, err := ParseComplex(tt.text); err != nil || z != tt.z {
+            t.Errorf("ParseComplex(%q) = %v, %v; want %v", tt.text, z, err, tt.z)
+        }
+    }
+
+    for _, text := range []string{"2i", " (1 + 2i) ", "3"} {
+        if _, err := ParseComplex(text); err != nil {
+            t.Errorf("ParseComplex(%q) failed: %v", text, err)
+        }
+    }
+    if _, err := ParseComplex("1+2j+"); !errors.Is(err, ErrSyntax) {
+        t.Errorf("expected ErrSyntax, got %v", err)
+    }
+}
+
+func TestComplexHistory(t *testing.T) {
+    calc := NewCalculator()
+    cc := calc.Complex()
+    cc.Add(1+2i, 3-1i)
+    cc.Divide(1i, 0)
+    cc.Abs(3 + 4i)
+    cc.Polar(-2)
+    cc.Rect(2, 0)
+
+    want := []string{
+        "1+2i + 3-1i = 4+1i",
+        "0+1i / 0+0i = error: divide(0+1i, 0+0i): division by zero",
+        "abs(3+4i) = 5",
+        "polar(-2+0i) = (2, 3.141592653589793)",
+        "rect(2, 0) = 2+0i",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+    if entries[0].Result != 4 || entries[0].Operands[1] != 3 {
+        t.Errorf("real parts not recorded: %+v", entries[0])
+    }
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "math/big"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// These tests are meant to be run with -race; they also check that no history entry is lost.
+
+func TestCalculatorConcurrentUse(t *testing.T) {
+    calc := NewCalculator()
+    const goroutines, rounds = 8, 25
+
+    // each round records exactly opsPerRound history entries
+    round := func(g, i int) {
+        x := float64(g*rounds + i + 1)
+        calc.Add(x, 1)
+        calc.Subtract(x, 1)
+        calc.Multiply(x, 2)
+        calc.Divide(x, 0) // recorded as a failure
+        calc.Power(x, 2)
+        calc.Sqrt(x)
+        calc.Sin(x)
+        calc.Mod(x, 3)
+        calc.Evaluate("2 * (3 + x)")
+        calc.EvaluateWith("2 * (3 + x)", map[string]float64{"x": x})
+        calc.Apply("^", 2, 3)
+        calc.Mean([]float64{x, x + 1})
+        calc.Precise(0, big.ToNearestEven).Add(big.NewRat(1, 3), big.NewRat(1, 6))
+        if p, err := calc.Programmer(IntMode{Bits: 8}); err == nil {
+            p.Xor(uint64(g), uint64(i))
+        }
+
+        // operations that do not record anything
+        calc.SetAngleMode(AngleMode(i % 2))
+        calc.AngleMode()
+        calc.History().Last(3)
+        calc.Operators()
+        if i%10 == 0 { // these format the whole history
+            calc.GetHistory()
+            calc.ExportHistory(&bytes.Buffer{})
+        }
+        calc.Register(Operator{Name: fmt.Sprintf("op_%d_%d", g, i), Arity: 1, Fn: func(args ...float64) (float64, error) {
+            return args[0], nil
+        }})
+    }
+    const opsPerRound = 14
+
+    var wg sync.WaitGroup
+    for g := 0; g < goroutines; g++ {
+        wg.Add(1)
+        go func(g int) {
+            defer wg.Done()
+            for i := 0; i < rounds; i++ {
+                round(g, i)
+            }
+        }(g)
+    }
+    wg.Wait()
+
+    if got, want := len(calc.History()), goroutines*rounds*opsPerRound; got != want {
+        t.Errorf("history has %d entries, want %d", got, want)
+    }
+    if got, want := len(calc.Operators()), len(NewCalculator().Operators())+goroutines*rounds; got != want {
+        t.Errorf("registry has %d operators, want %d", got, want)
+    }
+}
+
+func TestCalculatorConcurrentClearAndImport(t *testing.T) {
+    calc := NewCalculator()
+    calc.Add(1, 2)
+    var exported bytes.Buffer
+    if err := calc.ExportHistory(&exported); err != nil {
+        t.Fatalf("export failed: %v", err)
+    }
+
+    var wg sync.WaitGroup
+    for g := 0; g < 4; g++ {
+        wg.Add(3)
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                calc.Add(float64(i), 1)
+            }
+        }()
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                calc.ClearHistory()
+            }
+        }()
+        go func() {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                if err := calc.ImportHistory(strings.NewReader(exported.String())); err != nil {
+                    t.Errorf("import failed: %v", err)
+                    return
+                }
+            }
+        }()
+    }
+    wg.Wait()
+}
+
+func TestSessionConcurrentUse(t *testing.T) {
+    m := NewSessionManager()
+    const goroutines, rounds = 8, 100
+
+    var wg sync.WaitGroup
+    for g := 0; g < goroutines; g++ {
+        wg.Add(1)
+        go func(g int) {
+            defer wg.Done()
+            // every goroutine shares "shared" and owns one private session
+            shared := m.Get("shared")
+            private := m.Get(fmt.Sprintf("user-%d", g))
+            for i := 0; i < rounds; i++ {
+                shared.Add(1)
+                private.Add(1)
+                private.Multiply(1)
+                private.Undo()
+                private.Redo()
+                m.Names()
+            }
+            if got := private.Value(); got != rounds {
+                t.Errorf("private session %d = %v, want %d", g, got, rounds)
+            }
+        }(g)
+    }
+    wg.Wait()
+
+    if got := m.Get("shared").Value(); got != goroutines*rounds {
+        t.Errorf("shared session = %v, want %d", got, goroutines*rounds)
+    }
+    if got := len(m.Names()); got != goroutines+1 {
+        t.Errorf("manager has %d sessions, want %d", got, goroutines+1)
+    }
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// Sentinel errors returned, wrapped in a *CalcError, by Calculator operations.
+// Test for them with errors.Is.
+var (
+    ErrNaN             = errors.New("invalid input: NaN values")
+    ErrInfinite        = errors.New("invalid input: infinite values")
+    ErrOverflow        = errors.New("result overflow")
+    ErrDivisionByZero  = errors.New("division by zero")
+    ErrNegativeBase    = errors.New("invalid operation: negative base with non-integer exponent")
+    ErrSyntax          = errors.New("syntax error")
+    ErrUnknownVariable = errors.New("unknown variable")
+    ErrUnknownOperator = errors.New("unknown operator")
+    ErrNothingToUndo   = errors.New("nothing to undo")
+    ErrNothingToRedo   = errors.New("nothing to redo")
+    ErrNotReplayable   = errors.New("cannot be replayed")
+)
+
+// CalcError records the operation and operands that caused an error
+type CalcError struct {
+    Op          string
+    Operands    []float64
+    OperandText []string // operands that are not plain floats, e.g. "1+2i"; preferred in messages
+    Err         error
+}
+
+func (e *CalcError) Error() string {
+    operands := e.OperandText
+    if operands == nil {
+        operands = make([]string, len(e.Operands))
+        for i, operand := range e.Operands {
+            operands[i] = strconv.FormatFloat(operand, 'g', -1, 64)
+        }
+    }
+    return fmt.Sprintf("%s(%s): %v", e.Op, strings.Join(operands, ", "), e.Err)
+}
+
+func (e *CalcError) Unwrap() error {
+    return e.Err
+}
+
+// Unwrap lets errors.Is(err, ErrSyntax) match any *ParseError
+func (e *ParseError) Unwrap() error {
+    return ErrSyntax
+}
+
+// sentinels lists the errors that survive a JSON round trip of the history
+var sentinels = []error{
+    ErrNaN, ErrInfinite, ErrOverflow, ErrDivisionByZero, ErrNegativeBase,
+    ErrDomain, ErrSyntax, ErrUnknownVariable, ErrUnknownOperator,
+    ErrEmptyDataset, ErrDimensionMismatch, ErrSingularMatrix,
+    ErrIncompatibleUnits, ErrUnknownUnit, ErrNotDifferentiable, ErrNoConvergence, ErrNotBracketed,
+}
+
+// restoreError rebuilds an error from its message so that errors.Is keeps
+// working for sentinel errors after the history has been imported
+func restoreError(msg string) error {
+    for _, sentinel := range sentinels {
+        if msg == sentinel.Error() {
+            return sentinel
+        }
+        if prefix, ok := strings.CutSuffix(msg, ": "+sentinel.Error()); ok {
+            return fmt.Errorf("%s: %w", prefix, sentinel)
+        }
+        if suffix, ok := strings.CutPrefix(msg, sentinel.Error()+" "); ok {
+            return fmt.Errorf("%w %s", sentinel, suffix)
+        }
+    }
+    return errors.New(msg)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestCalcError(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        op       func(a, b float64) (float64, error)
+        a, b     float64
+        wantOp   string
+        wantErr  error
+        expected string
+    }{
+        {"divide by zero", calc.Divide, 1, 0, "divide", ErrDivisionByZero, "divide(1, 0): division by zero"},
+        {"add NaN", calc.Add, math.NaN(), 1, "add", ErrNaN, "add(NaN, 1): invalid input: NaN values"},
+        {"multiply overflow", calc.Multiply, 1e200, 1e200, "multiply", ErrOverflow, "multiply(1e+200, 1e+200): result overflow"},
+        {"subtract Inf", calc.Subtract, 1, math.Inf(-1), "subtract", ErrInfinite, "subtract(1, -Inf): invalid input: infinite values"},
+        {"negative base", calc.Power, -8, 1.0 / 3, "power", ErrNegativeBase, "power(-8, 0.3333333333333333): invalid operation: negative base with non-integer exponent"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := tt.op(tt.a, tt.b)
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("expected %v, got %v", tt.wantErr, err)
+            }
+            var calcErr *CalcError
+            if !errors.As(err, &calcErr) {
+                t.Fatalf("expected *CalcError, got %T", err)
+            }
+            if calcErr.Op != tt.wantOp || len(calcErr.Operands) != 2 {
+                t.Errorf("unexpected CalcError: %+v", calcErr)
+            }
+            if err.Error() != tt.expected {
+                t.Errorf("Error() = %q, want %q", err.Error(), tt.expected)
+            }
+        })
+    }
+}
+
+func TestEvaluateCalcError(t *testing.T) {
+    _, err := NewCalculator().Evaluate("2 * (3 / (1 - 1))")
+    var calcErr *CalcError
+    if !errors.As(err, &calcErr) {
+        t.Fatalf("expected *CalcError, got %v", err)
+    }
+    if calcErr.Op != "divide" || calcErr.Operands[0] != 3 || calcErr.Operands[1] != 0 {
+        t.Errorf("unexpected CalcError: %+v", calcErr)
+    }
+}
+
+func TestImportedErrorsKeepIdentity(t *testing.T) {
+    calc := NewCalculator()
+    calc.Divide(1, 0)
+    calc.Evaluate("x")
+
+    var buf bytes.Buffer
+    if err := calc.ExportHistory(&buf); err != nil {
+        t.Fatalf("ExportHistory failed: %v", err)
+    }
+    restored := NewCalculator()
+    if err := restored.ImportHistory(&buf); err != nil {
+        t.Fatalf("ImportHistory failed: %v", err)
+    }
+
+    history := restored.History()
+    if !errors.Is(history[0].Err, ErrDivisionByZero) {
+        t.Errorf("expected imported error to match ErrDivisionByZero, got %v", history[0].Err)
+    }
+    if !errors.Is(history[1].Err, ErrUnknownVariable) {
+        t.Errorf("expected imported error to match ErrUnknownVariable, got %v", history[1].Err)
+    }
+    if history[0].Err.Error() != "divide(1, 0): division by zero" {
+        t.Errorf("imported error message changed: %q", history[0].Err)
+    }
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// Expr is a node of a parsed arithmetic expression
+type Expr interface {
+    String() string
+}
+
+// NumberExpr is a numeric literal
+type NumberExpr struct {
+    Value float64
+}
+
+// VariableExpr refers to a named value supplied at evaluation time
+type VariableExpr struct {
+    Name string
+}
+
+// CallExpr calls a registered operator by name, e.g. "mod(7, 3)"
+type CallExpr struct {
+    Name string
+    Args []Expr
+}
+
+// UnaryExpr applies a prefix operator ("-" or "+") to its operand
+type UnaryExpr struct {
+    Op      string
+    Operand Expr
+}
+
+// BinaryExpr applies an infix operator to two operands
+type BinaryExpr struct {
+    Op          string
+    Left, Right Expr
+}
+
+// ParseError describes a syntax error and the column where it happened
+type ParseError struct {
+    Column int
+    Msg    string
+}
+
+func (e *ParseError) Error() string {
+    return fmt.Sprintf("parse error at column %d: %s", e.Column, e.Msg)
+}
+
+// Evaluate parses and evaluates an infix expression such as "2 * (3 + 4) ^ 2".
+// The whole expression is recorded as a single history entry.
+func (c *Calculator) Evaluate(expr string) (float64, error) {
+    return c.EvaluateWith(expr, nil)
+}
+
+// EvaluateWith is Evaluate for expressions that refer to variables such as "x * 2"
+func (c *Calculator) EvaluateWith(expr string, vars map[string]float64) (float64, error) {
+    result, err := c.evaluate(expr, vars)
+    c.record(HistoryEntry{Operator: "eval", Expression: strings.TrimSpace(expr), Result: result, Err: err})
+    if err != nil {
+        return 0, err
+    }
+    return result, nil
+}
+
+func (c *Calculator) evaluate(expr string, vars map[string]float64) (float64, error) {
+    tree, err := Parse(expr)
+    if err != nil {
+        return 0, err
+    }
+    return c.eval(tree, vars)
+}
+
+// eval walks the tree without recording any history
+func (c *Calculator) eval(e Expr, vars map[string]float64) (float64, error) {
+    switch n := e.(type) {
+    case *NumberExpr:
+        return n.Value, nil
+    case *VariableExpr:
+        v, ok := vars[n.Name]
+        if !ok {
+            return 0, fmt.Errorf("%w %q", ErrUnknownVariable, n.Name)
+        }
+        return v, nil
+    case *UnaryExpr:
+        v, err := c.eval(n.Operand, vars)
+        if err != nil {
+            return 0, err
+        }
+        if n.Op == "-" {
+            return -v, nil
+        }
+        return v, nil
+    case *BinaryExpr:
+        left, err := c.eval(n.Left, vars)
+        if err != nil {
+            return 0, err
+        }
+        right, err := c.eval(n.Right, vars)
+        if err != nil {
+            return 0, err
+        }
+        return c.registry.Call(n.Op, left, right)
+    case *CallExpr:
+        args := make([]float64, len(n.Args))
+        for i, arg := range n.Args {
+            v, err := c.eval(arg, vars)
+            if err != nil {
+                return 0, err
+            }
+            args[i] = v
+        }
+        return c.registry.Call(n.Name, args...)
+    }
+    return 0, fmt.Errorf("unsupported expression %T", e)
+}
+
+// Parse turns an infix expression into an expression tree.
+// Precedence from lowest to highest is: + -, * / %, unary -, ^ (right-associative).
+// Any registered operator can also be called by name, as in "root(27, 3)".
+func Parse(expr string) (Expr, error) {
+    tokens, err := tokenize(expr)
+    if err != nil {
+        return nil, err
+    }
+    p := &parser{tokens: tokens}
+    tree, err := p.parseExpr()
+    if err != nil {
+        return nil, err
+    }
+    if tok := p.peek(); tok.kind != tokenEOF {
+        return nil, &ParseError{Column: tok.col, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+    }
+    return tree, nil
+}
+
+type tokenKind int
+
+const (
+    tokenEOF tokenKind = iota
+    tokenNumber
+    tokenIdent
+    tokenOperator
+    tokenLParen
+    tokenRParen
+    tokenComma
+)
+
+type token struct {
+    kind  tokenKind
+    text  string
+    value float64
+    col   int
+}
+
+// tokenize splits an expression into tokens, recording 1-based columns
+func tokenize(expr string) ([]token, error) {
+    runes := []rune(expr)
+    var tokens []token
+    for i := 0; i < len(runes); {
+        r := runes[i]
+        col := i + 1
+        switch {
+        case unicode.IsSpace(r):
+            i++
+        case unicode.IsDigit(r) || r == '.':
+            start := i
+            for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+                i++
+            }
+            if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+                i++
+                if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+                    i++
+                }
+                for i < len(runes) && unicode.IsDigit(runes[i]) {
+                    i++
+                }
+            }
+            text := string(runes[start:i])
+            value, err := strconv.ParseFloat(text, 64)
+            if err != nil {
+                if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+                    return nil, &ParseError{Column: col, Msg: fmt.Sprintf("number %q out of range", text)}
+                }
+                return nil, &ParseError{Column: col, Msg: fmt.Sprintf("malformed number %q", text)}
+            }
+            tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, col: col})
+        case isIdentStart(r):
+            start := i
+            for i < len(runes) && (isIdentStart(runes[i]) || unicode.IsDigit(runes[i])) {
+                i++
+            }
+            tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), col: col})
+        case strings.ContainsRune("+-*/^%", r):
+            tokens = append(tokens, token{kind: tokenOperator, text: string(r), col: col})
+            i++
+        case r == '(':
+            tokens = append(tokens, token{kind: tokenLParen, text: "(", col: col})
+            i++
+        case r == ')':
+            tokens = append(tokens, token{kind: tokenRParen, text: ")", col: col})
+            i++
+        case r == ',':
+            tokens = append(tokens, token{kind: tokenComma, text: ",", col: col})
+            i++
+        default:
+            return nil, &ParseError{Column: col, Msg: fmt.Sprintf("unexpected character %q", r)}
+        }
+    }
+    tokens = append(tokens, token{kind: tokenEOF, text: "end of input", col: len(runes) + 1})
+    return tokens, nil
+}
+
+// isIdentStart reports whether r can begin a name; '°' allows unit names such as °C
+func isIdentStart(r rune) bool {
+    return unicode.IsLetter(r) || r == '_' || r == '°'
+}
+
+// parser is a recursive-descent parser over a token slice
+type parser struct {
+    tokens []token
+    pos    int
+}
+
+func (p *parser) peek() token {
+    return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+    tok := p.tokens[p.pos]
+    if tok.kind != tokenEOF {
+        p.pos++
+    }
+    return tok
+}
+
+// parseExpr handles addition and subtraction
+func (p *parser) parseExpr() (Expr, error) {
+    left, err := p.parseTerm()
+    if err != nil {
+        return nil, err
+    }
+    for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+        p.next()
+        right, err := p.parseTerm()
+        if err != nil {
+            return nil, err
+        }
+        left = &BinaryExpr{Op: tok.text, Left: left, Right: right}
+    }
+    return left, nil
+}
+
+// parseTerm handles multiplication, division and modulo
+func (p *parser) parseTerm() (Expr, error) {
+    left, err := p.parseUnary()
+    if err != nil {
+        return nil, err
+    }
+    for tok := p.peek(); tok.kind == tokenOperator && (tok.text == "*" || tok.text == "/" || tok.text == "%"); tok = p.peek() {
+        p.next()
+        right, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        left = &BinaryExpr{Op: tok.text, Left: left, Right: right}
+    }
+    return left, nil
+}
+
+// parseUnary handles prefix signs, which bind looser than ^ so that -2^2 == -4
+func (p *parser) parseUnary() (Expr, error) {
+    if tok := p.peek(); tok.kind == tokenOperator && (tok.text == "-" || tok.text == "+") {
+        p.next()
+        operand, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        return &UnaryExpr{Op: tok.text, Operand: operand}, nil
+    }
+    return p.parsePower()
+}
+
+// parsePower handles right-associative exponentiation
+func (p *parser) parsePower() (Expr, error) {
+    base, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    if tok := p.peek(); tok.kind == tokenOperator && tok.text == "^" {
+        p.next()
+        exponent, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        return &BinaryExpr{Op: "^", Left: base, Right: exponent}, nil
+    }
+    return base, nil
+}
+
+// parsePrimary handles numbers, variables, calls and parenthesised sub-expressions
+func (p *parser) parsePrimary() (Expr, error) {
+    tok := p.next()
+    switch tok.kind {
+    case tokenNumber:
+        return &NumberExpr{Value: tok.value}, nil
+    case tokenIdent:
+        if p.peek().kind == tokenLParen {
+            p.next()
+            return p.parseCall(tok.text)
+        }
+        return &VariableExpr{Name: tok.text}, nil
+    case tokenLParen:
+        inner, err := p.parseExpr()
+        if err != nil {
+            return nil, err
+        }
+        if closing := p.next(); closing.kind != tokenRParen {
+            return nil, &ParseError{Column: closing.col, Msg: fmt.Sprintf("expected \")\" but found %q", closing.text)}
+        }
+        return inner, nil
+    }
+    return nil, &ParseError{Column: tok.col, Msg: fmt.Sprintf("expected a number, variable or \"(\" but found %q", tok.text)}
+}
+
+// parseCall parses the argument list of name(...) after its opening parenthesis
+func (p *parser) parseCall(name string) (Expr, error) {
+    call := &CallExpr{Name: name}
+    if p.peek().kind == tokenRParen {
+        p.next()
+        return call, nil
+    }
+    for {
+        arg, err := p.parseExpr()
+        if err != nil {
+            return nil, err
+        }
+        call.Args = append(call.Args, arg)
+        switch tok := p.next(); tok.kind {
+        case tokenComma:
+            continue
+        case tokenRParen:
+            return call, nil
+        default:
+            return nil, &ParseError{Column: tok.col, Msg: fmt.Sprintf("expected \",\" or \")\" but found %q", tok.text)}
+        }
+    }
+}
+
+// precedence levels used when printing expressions back to infix
+const (
+    precSum = iota + 1
+    precProduct
+    precUnary
+    precPower
+    precAtom
+)
+
+func precedence(e Expr) int {
+    switch n := e.(type) {
+    case *NumberExpr:
+        if n.Value < 0 {
+            return precUnary
+        }
+    case *UnaryExpr:
+        return precUnary
+    case *BinaryExpr:
+        switch n.Op {
+        case "+", "-":
+            return precSum
+        case "*", "/", "%":
+            return precProduct
+        case "^":
+            return precPower
+        }
+    }
+    return precAtom
+}
+
+func wrap(e Expr, parens bool) string {
+    if parens {
+        return "(" + e.String() + ")"
+    }
+    return e.String()
+}
+
+func (n *NumberExpr) String() string {
+    return strconv.FormatFloat(n.Value, 'g', -1, 64)
+}
+
+func (n *VariableExpr) String() string {
+    return n.Name
+}
+
+func (n *CallExpr) String() string {
+    args := make([]string, len(n.Args))
+    for i, arg := range n.Args {
+        args[i] = arg.String()
+    }
+    return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (n *UnaryExpr) String() string {
+    return n.Op + wrap(n.Operand, precedence(n.Operand) <= precUnary)
+}
+
+func (n *BinaryExpr) String() string {
+    prec := precedence(n)
+    // ^ groups to the right, every other operator groups to the left
+    leftParens := precedence(n.Left) < prec || (n.Op == "^" && precedence(n.Left) == prec)
+    rightParens := precedence(n.Right) < prec || (n.Op != "^" && precedence(n.Right) == prec)
+    if n.Op == "^" && precedence(n.Left) == precUnary {
+        leftParens = true
+    }
+    return wrap(n.Left, leftParens) + " " + n.Op + " " + wrap(n.Right, rightParens)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestEvaluate(t *testing.T) {
+    tests := []struct {
+        name     string
+        expr     string
+        expected float64
+        wantErr  error
+    }{
+        {"single number", "42", 42, nil},
+        {"precedence", "2 + 3 * 4", 14, nil},
+        {"left associative", "10 - 4 - 3", 3, nil},
+        {"parentheses", "(2 + 3) * 4", 20, nil},
+        {"nested parentheses", "((1 + 2) * (3 + 4)) / 7", 3, nil},
+        {"right associative power", "2 ^ 3 ^ 2", 512, nil},
+        {"unary minus", "-3 + 5", 2, nil},
+        {"unary minus binds looser than power", "-2 ^ 2", -4, nil},
+        {"negative exponent", "2 ^ -1", 0.5, nil},
+        {"double negation", "--4", 4, nil},
+        {"decimals and exponents", "1.5e2 + .5", 150.5, nil},
+        {"division by zero", "1 / (2 - 2)", 0, ErrDivisionByZero},
+        {"overflow", "1e308 * 10", 0, ErrOverflow},
+        {"negative base with non-integer exponent", "(-8) ^ 0.5", 0, ErrNegativeBase},
+        {"unknown variable", "x + 1", 0, ErrUnknownVariable},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            calc := NewCalculator()
+            result, err := calc.Evaluate(tt.expr)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Errorf("unexpected error: %v", err)
+                return
+            }
+            if math.Abs(result-tt.expected) > 1e-9 {
+                t.Errorf("Evaluate(%q) = %v, want %v", tt.expr, result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestEvaluateParseErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        expr   string
+        column int
+    }{
+        {"empty", "", 1},
+        {"dangling operator", "2 +", 4},
+        {"unexpected character", "2 # 3", 3},
+        {"missing closing paren", "(1 + 2", 7},
+        {"extra closing paren", "1 + 2)", 6},
+        {"malformed number", "1.2.3", 1},
+        {"number out of range", "1 + 1e400", 5},
+        {"two numbers", "1 2", 3},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            calc := NewCalculator()
+            _, err := calc.Evaluate(tt.expr)
+            var parseErr *ParseError
+            if !errors.As(err, &parseErr) || !errors.Is(err, ErrSyntax) {
+                t.Fatalf("expected *ParseError, got %v", err)
+            }
+            if parseErr.Column != tt.column {
+                t.Errorf("Evaluate(%q) reported column %d, want %d", tt.expr, parseErr.Column, tt.column)
+            }
+        })
+    }
+}
+
+func TestEvaluateHistory(t *testing.T) {
+    calc := NewCalculator()
+    calc.Evaluate(" 2 + 3 * 4 ")
+    calc.Evaluate("1 / 0")
+
+    history := calc.GetHistory()
+    if len(history) != 1 {
+        t.Fatalf("expected history length 1, got %d", len(history))
+    }
+    if history[0] != "2 + 3 * 4 = 14.000000" {
+        t.Errorf("unexpected history entry: %s", history[0])
+    }
+}
+
+func TestExprString(t *testing.T) {
+    tests := []struct {
+        expr     string
+        expected string
+    }{
+        {"1+2*3", "1 + 2 * 3"},
+        {"(1+2)*3", "(1 + 2) * 3"},
+        {"1-(2-3)", "1 - (2 - 3)"},
+        {"(2^3)^2", "(2 ^ 3) ^ 2"},
+        {"2^3^2", "2 ^ 3 ^ 2"},
+        {"-(1+2)", "-(1 + 2)"},
+        {"(-2)^2", "(-2) ^ 2"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            tree, err := Parse(tt.expr)
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := tree.String(); got != tt.expected {
+                t.Errorf("String() = %q, want %q", got, tt.expected)
+            }
+        })
+    }
+}
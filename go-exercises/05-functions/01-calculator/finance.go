@@ -0,0 +1,385 @@
+package main
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "math"
+    "math/big"
+    "strconv"
+    "time"
+)
+
+// PaymentTiming says whether payments fall at the end or the beginning of each
+// period, like the type argument of spreadsheet functions
+type PaymentTiming int
+
+const (
+    EndOfPeriod PaymentTiming = iota
+    BeginningOfPeriod
+)
+
+// rateGuess is the starting rate for RATE, IRR and XIRR, as in spreadsheets
+const rateGuess = 0.1
+
+// The time-value functions follow the spreadsheet sign convention: money paid
+// out is negative and money received positive, so a loan of 1000 has pv 1000
+// and a negative pmt. rate is the interest rate per period.
+
+// PV returns the present value of nper payments of pmt plus a final fv
+func (c *Calculator) PV(rate, nper, pmt, fv float64, when PaymentTiming) (float64, error) {
+    return c.finance("pv", []float64{rate, nper, pmt, fv, float64(when)}, func() (float64, error) {
+        if rate == 0 {
+            return -(fv + pmt*nper), nil
+        }
+        growth := math.Pow(1+rate, nper)
+        return -(fv + pmt*annuityFactor(rate, growth, when)) / growth, nil
+    })
+}
+
+// FV returns the future value of pv after nper payments of pmt
+func (c *Calculator) FV(rate, nper, pmt, pv float64, when PaymentTiming) (float64, error) {
+    return c.finance("fv", []float64{rate, nper, pmt, pv, float64(when)}, func() (float64, error) {
+        return -futureValue(rate, nper, pmt, pv, when), nil
+    })
+}
+
+// PMT returns the payment per period that turns pv into fv over nper periods
+func (c *Calculator) PMT(rate, nper, pv, fv float64, when PaymentTiming) (float64, error) {
+    return c.finance("pmt", []float64{rate, nper, pv, fv, float64(when)}, func() (float64, error) {
+        if nper == 0 {
+            return 0, fmt.Errorf("%w: nper must not be 0", ErrDomain)
+        }
+        if rate == 0 {
+            return -(pv + fv) / nper, nil
+        }
+        growth := math.Pow(1+rate, nper)
+        return -(fv + pv*growth) / annuityFactor(rate, growth, when), nil
+    })
+}
+
+// NPER returns the number of periods of pmt that turn pv into fv
+func (c *Calculator) NPER(rate, pmt, pv, fv float64, when PaymentTiming) (float64, error) {
+    return c.finance("nper", []float64{rate, pmt, pv, fv, float64(when)}, func() (float64, error) {
+        if rate == 0 {
+            if pmt == 0 {
+                return 0, ErrDivisionByZero
+            }
+            return -(pv + fv) / pmt, nil
+        }
+        payment := pmt * (1 + rate*float64(when)) / rate
+        ratio := (payment - fv) / (payment + pv)
+        if !(ratio > 0) {
+            return 0, fmt.Errorf("%w: the payments never reach fv", ErrDomain)
+        }
+        return math.Log(ratio) / math.Log1p(rate), nil
+    })
+}
+
+// RATE returns the interest rate per period at which nper payments of pmt turn
+// pv into fv. It is found iteratively from a guess of 10%.
+func (c *Calculator) RATE(nper, pmt, pv, fv float64, when PaymentTiming, opts SolverOptions) (float64, error) {
+    return c.finance("rate", []float64{nper, pmt, pv, fv, float64(when)}, func() (float64, error) {
+        return solveRate(func(rate float64) float64 {
+            return futureValue(rate, nper, pmt, pv, when) + fv
+        }, nil, opts)
+    })
+}
+
+// NPV returns the net present value of cash flows at the end of periods 1, 2, ...
+func (c *Calculator) NPV(rate float64, flows []float64) (float64, error) {
+    return c.finance("npv", append([]float64{rate}, flows...), func() (float64, error) {
+        if len(flows) == 0 {
+            return 0, ErrEmptyDataset
+        }
+        return presentValue(rate, flows, 1), nil
+    })
+}
+
+// IRR returns the rate at which the NPV of flows, the first one undiscounted, is zero
+func (c *Calculator) IRR(flows []float64, opts SolverOptions) (float64, error) {
+    return c.finance("irr", flows, func() (float64, error) {
+        if err := checkFlows(flows); err != nil {
+            return 0, err
+        }
+        return solveRate(func(rate float64) float64 {
+            return presentValue(rate, flows, 0)
+        }, func(rate float64) float64 {
+            slope := 0.0
+            for i, flow := range flows {
+                slope -= float64(i) * flow / math.Pow(1+rate, float64(i+1))
+            }
+            return slope
+        }, opts)
+    })
+}
+
+// XIRR is IRR for flows on arbitrary dates, discounted by the days since the first date over 365
+func (c *Calculator) XIRR(flows []float64, dates []time.Time, opts SolverOptions) (float64, error) {
+    return c.finance("xirr", flows, func() (float64, error) {
+        if len(dates) != len(flows) {
+            return 0, fmt.Errorf("%w: %d cash flows and %d dates", ErrDimensionMismatch, len(flows), len(dates))
+        }
+        if err := checkFlows(flows); err != nil {
+            return 0, err
+        }
+        years := make([]float64, len(dates))
+        for i, date := range dates {
+            years[i] = date.Sub(dates[0]).Hours() / 24 / 365
+            if years[i] < 0 {
+                return 0, fmt.Errorf("%w: date %d is before the first date", ErrDomain, i)
+            }
+        }
+        return solveRate(func(rate float64) float64 {
+            sum := 0.0
+            for i, flow := range flows {
+                sum += flow / math.Pow(1+rate, years[i])
+            }
+            return sum
+        }, func(rate float64) float64 {
+            slope := 0.0
+            for i, flow := range flows {
+                slope -= years[i] * flow / math.Pow(1+rate, years[i]+1)
+            }
+            return slope
+        }, opts)
+    })
+}
+
+// annuityFactor is the future value of paying 1 per period
+func annuityFactor(rate, growth float64, when PaymentTiming) float64 {
+    return (1 + rate*float64(when)) * (growth - 1) / rate
+}
+
+// futureValue is the balance after nper periods, the negation of FV
+func futureValue(rate, nper, pmt, pv float64, when PaymentTiming) float64 {
+    if rate == 0 {
+        return pv + pmt*nper
+    }
+    growth := math.Pow(1+rate, nper)
+    return pv*growth + pmt*annuityFactor(rate, growth, when)
+}
+
+// presentValue discounts flows[i] by i+first periods
+func presentValue(rate float64, flows []float64, first int) float64 {
+    sum := 0.0
+    for i, flow := range flows {
+        sum += flow / math.Pow(1+rate, float64(i+first))
+    }
+    return sum
+}
+
+// checkFlows requires both money in and money out, or no rate can balance them
+func checkFlows(flows []float64) error {
+    var in, out bool
+    for _, flow := range flows {
+        in = in || flow > 0
+        out = out || flow < 0
+    }
+    if !in || !out {
+        return fmt.Errorf("%w: cash flows need both positive and negative values", ErrDomain)
+    }
+    return nil
+}
+
+// rateBrackets are the rates scanned for a sign change when Newton's method fails
+var rateBrackets = []float64{-0.99, -0.9, -0.5, -0.1, 0, 0.01, 0.1, 0.5, 1, 10, 100, 1000}
+
+// solveRate finds a root above -100% with Newton's method from rateGuess. When
+// that fails it looks for neighbouring rateBrackets where f changes sign and
+// narrows the bracket with Brent's method.
+func solveRate(f, df func(float64) float64, opts SolverOptions) (float64, error) {
+    opts, err := opts.withDefaults()
+    if err != nil {
+        return 0, err
+    }
+    rate, _, err := newton(f, df, rateGuess, opts)
+    if err == nil && rate > -1 {
+        return rate, nil
+    }
+    if err == nil {
+        err = fmt.Errorf("%w: rate %s is not above -100%%", ErrDomain, formatFloat(rate))
+    }
+    lo, flo := math.NaN(), math.NaN()
+    for _, hi := range rateBrackets {
+        fhi := f(hi)
+        if math.IsNaN(fhi) || math.IsInf(fhi, 0) {
+            continue
+        }
+        if !math.IsNaN(flo) && math.Signbit(flo) != math.Signbit(fhi) {
+            rate, _, err = brent(f, lo, hi, opts)
+            return rate, err
+        }
+        lo, flo = hi, fhi
+    }
+    return 0, err
+}
+
+// finance validates the arguments, runs fn and records the function like the
+// scalar operators, e.g. "pmt(0.005, 360, 200000, 0, 0) = -1199.101050"
+func (c *Calculator) finance(name string, args []float64, fn func() (float64, error)) (float64, error) {
+    entry := HistoryEntry{Operator: name, Operands: args}
+    err := checkOperands(args)
+    var result float64
+    if err == nil {
+        result, err = fn()
+    }
+    if err == nil && (math.IsNaN(result) || math.IsInf(result, 0)) {
+        err = ErrOverflow
+    }
+    if err != nil {
+        err = &CalcError{Op: name, Operands: args, Err: err}
+        entry.Err = err
+        c.record(entry)
+        return 0, err
+    }
+    entry.Result = result
+    c.record(entry)
+    return result, nil
+}
+
+// Cents is an amount of money in hundredths, so that schedules add up exactly
+type Cents int64
+
+// String formats the amount with two decimals, e.g. "-1199.10"
+func (c Cents) String() string {
+    sign := ""
+    if c < 0 {
+        sign, c = "-", -c
+    }
+    return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// AmortizationRow is one payment of a loan
+type AmortizationRow struct {
+    Period    int
+    Payment   Cents
+    Interest  Cents
+    Principal Cents
+    Balance   Cents // left after this payment
+}
+
+// AmortizationSchedule lists the payments that repay a loan
+type AmortizationSchedule struct {
+    Payment Cents // the regular payment; the last one may differ by a few cents
+    Rows    []AmortizationRow
+}
+
+// TotalInterest adds up the interest paid over the schedule
+func (s AmortizationSchedule) TotalInterest() Cents {
+    var total Cents
+    for _, row := range s.Rows {
+        total += row.Interest
+    }
+    return total
+}
+
+// WriteCSV writes the schedule as CSV with a header row
+func (s AmortizationSchedule) WriteCSV(w io.Writer) error {
+    out := csv.NewWriter(w)
+    out.Write([]string{"period", "payment", "interest", "principal", "balance"})
+    for _, row := range s.Rows {
+        out.Write([]string{
+            strconv.Itoa(row.Period), row.Payment.String(), row.Interest.String(), row.Principal.String(), row.Balance.String(),
+        })
+    }
+    out.Flush()
+    return out.Error()
+}
+
+// Amortization schedules equal end-of-period payments that repay principal over
+// periods at rate per period. Amounts are rounded to cents with exact rational
+// arithmetic: each period's interest is rounded half away from zero and the last
+// payment absorbs the rounding, so the balance ends at exactly 0.
+func (c *Calculator) Amortization(principal, rate float64, periods int) (AmortizationSchedule, error) {
+    var schedule AmortizationSchedule
+    args := []float64{principal, rate, float64(periods)}
+    entry := HistoryEntry{Operator: "amortization", Operands: args}
+    err := checkOperands(args)
+    if err == nil {
+        schedule, err = amortize(principal, rate, periods)
+    }
+    if err != nil {
+        err = &CalcError{Op: "amortization", Operands: args, Err: err}
+        entry.Err = err
+        c.record(entry)
+        return AmortizationSchedule{}, err
+    }
+    entry.Result = float64(schedule.Payment) / 100
+    entry.ResultText = fmt.Sprintf("%d payments of %s", len(schedule.Rows), schedule.Payment)
+    c.record(entry)
+    return schedule, nil
+}
+
+// maxPeriods keeps the exact powers of 1+rate to a manageable size
+const maxPeriods = 1200
+
+func amortize(principal, rate float64, periods int) (AmortizationSchedule, error) {
+    switch {
+    case principal <= 0:
+        return AmortizationSchedule{}, fmt.Errorf("%w: principal must be positive", ErrDomain)
+    case rate < 0:
+        return AmortizationSchedule{}, fmt.Errorf("%w: rate must not be negative", ErrDomain)
+    case periods < 1 || periods > maxPeriods:
+        return AmortizationSchedule{}, fmt.Errorf("%w: periods must be between 1 and %d", ErrDomain, maxPeriods)
+    }
+    balance, err := toCents(new(big.Rat).SetFloat64(principal))
+    if err != nil {
+        return AmortizationSchedule{}, err
+    }
+    r := new(big.Rat).SetFloat64(rate)
+
+    // payment = P r g / (g - 1) with g = (1 + r)^n, or P / n without interest, in cents
+    payment := new(big.Rat).SetInt64(int64(balance))
+    if rate == 0 {
+        payment.Quo(payment, big.NewRat(int64(periods), 1))
+    } else {
+        growth, err := ratPow(new(big.Rat).Add(big.NewRat(1, 1), r), big.NewInt(int64(periods)))
+        if err != nil {
+            return AmortizationSchedule{}, err
+        }
+        payment.Mul(payment, r).Mul(payment, growth)
+        payment.Quo(payment, growth.Sub(growth, big.NewRat(1, 1)))
+    }
+    regular, err := roundCents(payment)
+    if err != nil {
+        return AmortizationSchedule{}, err
+    }
+
+    schedule := AmortizationSchedule{Payment: regular}
+    for period := 1; period <= periods && balance > 0; period++ {
+        interest, err := roundCents(new(big.Rat).Mul(big.NewRat(int64(balance), 1), r))
+        if err != nil {
+            return AmortizationSchedule{}, err
+        }
+        row := AmortizationRow{Period: period, Payment: regular, Interest: interest, Principal: regular - interest}
+        if period == periods || row.Principal > balance {
+            row.Principal = balance
+            row.Payment = balance + interest
+        }
+        balance -= row.Principal
+        row.Balance = balance
+        schedule.Rows = append(schedule.Rows, row)
+    }
+    return schedule, nil
+}
+
+// toCents converts an amount in currency units to cents
+func toCents(amount *big.Rat) (Cents, error) {
+    return roundCents(new(big.Rat).Mul(amount, big.NewRat(100, 1)))
+}
+
+// roundCents rounds an amount in cents to a whole cent, half away from zero
+func roundCents(cents *big.Rat) (Cents, error) {
+    num, den := cents.Num(), cents.Denom()
+    // |num| / den + 1/2 = (2|num| + den) / 2den, truncated
+    twice := new(big.Int).Mul(new(big.Int).Abs(num), big.NewInt(2))
+    rounded := new(big.Int).Quo(twice.Add(twice, den), new(big.Int).Mul(den, big.NewInt(2)))
+    if num.Sign() < 0 {
+        rounded.Neg(rounded)
+    }
+    if !rounded.IsInt64() {
+        return 0, ErrOverflow
+    }
+    return Cents(rounded.Int64()), nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "math"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestTimeValueOfMoney(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name      string
+        op        func() (float64, error)
+        expected  float64
+        tolerance float64
+        wantErr   error
+    }{
+        // expected values are the examples from spreadsheet documentation
+        {"pv", func() (float64, error) { return calc.PV(0.08/12, 12*20, 500, 0, EndOfPeriod) }, -59777.15, 0.005, nil},
+        {"pv without interest", func() (float64, error) { return calc.PV(0, 10, -100, 0, EndOfPeriod) }, 1000, 0, nil},
+        {"fv in advance", func() (float64, error) { return calc.FV(0.06/12, 10, -200, -500, BeginningOfPeriod) }, 2581.40, 0.005, nil},
+        {"fv", func() (float64, error) { return calc.FV(0.005, 12, -100, 0, EndOfPeriod) }, 1233.56, 0.005, nil},
+        {"pmt", func() (float64, error) { return calc.PMT(0.08/12, 10, 10000, 0, EndOfPeriod) }, -1037.03, 0.005, nil},
+        {"pmt without interest", func() (float64, error) { return calc.PMT(0, 4, 1000, 0, EndOfPeriod) }, -250, 0, nil},
+        {"pmt with no periods", func() (float64, error) { return calc.PMT(0.01, 0, 1000, 0, EndOfPeriod) }, 0, 0, ErrDomain},
+        {"nper", func() (float64, error) { return calc.NPER(0.01, -100, -1000, 10000, EndOfPeriod) }, 60.0821229, 1e-7, nil},
+        {"nper in advance", func() (float64, error) { return calc.NPER(0.01, -100, -1000, 10000, BeginningOfPeriod) }, 59.6738657, 1e-7, nil},
+        {"nper never repaid", func() (float64, error) { return calc.NPER(0.01, -5, 1000, 0, EndOfPeriod) }, 0, 0, ErrDomain},
+        {"nper without payments", func() (float64, error) { return calc.NPER(0, 0, 1000, 0, EndOfPeriod) }, 0, 0, ErrDivisionByZero},
+        {"rate", func() (float64, error) { return calc.RATE(48, -200, 8000, 0, EndOfPeriod, SolverOptions{}) }, 0.0077014724, 1e-10, nil},
+        {"NaN rate", func() (float64, error) { return calc.PV(math.NaN(), 10, 1, 0, EndOfPeriod) }, 0, 0, ErrNaN},
+        {"infinite payment", func() (float64, error) { return calc.FV(0.1, 10, math.Inf(-1), 0, EndOfPeriod) }, 0, 0, ErrInfinite},
+        {"overflow", func() (float64, error) { return calc.FV(10, 1000, -1, 0, EndOfPeriod) }, 0, 0, ErrOverflow},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op()
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > tt.tolerance {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestTimeValueRoundTrip(t *testing.T) {
+    calc := NewCalculator()
+    pmt, _ := calc.PMT(0.004, 360, 250000, -10000, BeginningOfPeriod)
+    pv, _ := calc.PV(0.004, 360, pmt, -10000, BeginningOfPeriod)
+    nper, _ := calc.NPER(0.004, pmt, 250000, -10000, BeginningOfPeriod)
+    rate, err := calc.RATE(360, pmt, 250000, -10000, BeginningOfPeriod, SolverOptions{})
+    if err != nil {
+        t.Fatalf("RATE failed: %v", err)
+    }
+    if math.Abs(pv-250000) > 1e-6 || math.Abs(nper-360) > 1e-9 || math.Abs(rate-0.004) > 1e-9 {
+        t.Errorf("round trip gave pv %v, nper %v, rate %v", pv, nper, rate)
+    }
+}
+
+func TestCashFlows(t *testing.T) {
+    calc := NewCalculator()
+    date := func(year int, month time.Month, day int) time.Time {
+        return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+    }
+    tests := []struct {
+        name     string
+        op       func() (float64, error)
+        expected float64
+        wantErr  error
+    }{
+        {"npv", func() (float64, error) { return calc.NPV(0.1, []float64{-10000, 3000, 4200, 6800}) }, 1188.4434123352207, nil},
+        {"npv without flows", func() (float64, error) { return calc.NPV(0.1, nil) }, 0, ErrEmptyDataset},
+        {"irr", func() (float64, error) {
+            return calc.IRR([]float64{-70000, 12000, 15000, 18000, 21000, 26000}, SolverOptions{})
+        }, 0.086630948, nil},
+        {"negative irr", func() (float64, error) {
+            return calc.IRR([]float64{-70000, 12000, 15000, 18000, 21000}, SolverOptions{})
+        }, -0.021244848, nil},
+        {"irr without a sign change", func() (float64, error) { return calc.IRR([]float64{100, 200}, SolverOptions{}) }, 0, ErrDomain},
+        {"xirr", func() (float64, error) {
+            return calc.XIRR([]float64{-10000, 2750, 4250, 3250, 2750}, []time.Time{
+                date(2008, 1, 1), date(2008, 3, 1), date(2008, 10, 30), date(2009, 2, 15), date(2009, 4, 1),
+            }, SolverOptions{})
+        }, 0.37336253352, nil},
+        {"xirr with missing dates", func() (float64, error) {
+            return calc.XIRR([]float64{-100, 110}, []time.Time{date(2020, 1, 1)}, SolverOptions{})
+        }, 0, ErrDimensionMismatch},
+        {"xirr with an earlier date", func() (float64, error) {
+            return calc.XIRR([]float64{-100, 110}, []time.Time{date(2020, 1, 1), date(2019, 1, 1)}, SolverOptions{})
+        }, 0, ErrDomain},
+        {"NaN flow", func() (float64, error) { return calc.IRR([]float64{-100, math.NaN()}, SolverOptions{}) }, 0, ErrNaN},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op()
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > 1e-9 {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestAmortization(t *testing.T) {
+    calc := NewCalculator()
+    schedule, err := calc.Amortization(10000, 0.01, 12)
+    if err != nil {
+        t.Fatalf("Amortization failed: %v", err)
+    }
+    if schedule.Payment != 88849 || len(schedule.Rows) != 12 {
+        t.Fatalf("got %d rows of %v, want 12 of 888.49", len(schedule.Rows), schedule.Payment)
+    }
+    first := schedule.Rows[0]
+    if first != (AmortizationRow{Period: 1, Payment: 88849, Interest: 10000, Principal: 78849, Balance: 921151}) {
+        t.Errorf("unexpected first row %+v", first)
+    }
+    var principal, paid Cents
+    for _, row := range schedule.Rows {
+        if row.Payment != row.Interest+row.Principal {
+            t.Errorf("row %d does not add up: %+v", row.Period, row)
+        }
+        principal += row.Principal
+        paid += row.Payment
+    }
+    if last := schedule.Rows[11]; last.Balance != 0 || principal != 1000000 {
+        t.Errorf("loan not repaid exactly: last row %+v, principal %v", last, principal)
+    }
+    if paid-principal != schedule.TotalInterest() {
+        t.Errorf("TotalInterest() = %v, want %v", schedule.TotalInterest(), paid-principal)
+    }
+
+    flat, err := calc.Amortization(100, 0, 3)
+    if err != nil || flat.Payment != 3333 || flat.Rows[2].Payment != 3334 {
+        t.Errorf("interest-free schedule = %+v, %v", flat, err)
+    }
+
+    for _, args := range []struct {
+        principal, rate float64
+        periods         int
+        wantErr         error
+    }{
+        {0, 0.01, 12, ErrDomain},
+        {1000, -0.01, 12, ErrDomain},
+        {1000, 0.01, 0, ErrDomain},
+        {math.NaN(), 0.01, 12, ErrNaN},
+        {1e300, 0.01, 12, ErrOverflow},
+    } {
+        if _, err := calc.Amortization(args.principal, args.rate, args.periods); !errors.Is(err, args.wantErr) {
+            t.Errorf("Amortization(%v, %v, %d): expected %v, got %v", args.principal, args.rate, args.periods, args.wantErr, err)
+        }
+    }
+}
+
+func TestAmortizationCSV(t *testing.T) {
+    schedule, err := NewCalculator().Amortization(1000, 0.05, 2)
+    if err != nil {
+        t.Fatalf("Amortization failed: %v", err)
+    }
+    var buf bytes.Buffer
+    if err := schedule.WriteCSV(&buf); err != nil {
+        t.Fatalf("WriteCSV failed: %v", err)
+    }
+    want := strings.Join([]string{
+        "period,payment,interest,principal,balance",
+        "1,537.80,50.00,487.80,512.20",
+        "2,537.81,25.61,512.20,0.00",
+        "",
+    }, "\n")
+    if got := buf.String(); got != want {
+        t.Errorf("got\n%s\nwant\n%s", got, want)
+    }
+}
+
+func TestCents(t *testing.T) {
+    for _, tt := range []struct {
+        c    Cents
+        text string
+    }{
+        {0, "0.00"}, {5, "0.05"}, {-5, "-0.05"}, {123456, "1234.56"}, {-100, "-1.00"},
+    } {
+        if got := tt.c.String(); got != tt.text {
+            t.Errorf("Cents(%d).String() = %q, want %q", int64(tt.c), got, tt.text)
+        }
+    }
+}
+
+func TestFinanceHistory(t *testing.T) {
+    calc := NewCalculator()
+    calc.PMT(0, 4, 1000, 0, EndOfPeriod)
+    calc.Amortization(1000, 0.05, 2)
+
+    want := []string{
+        "pmt(0.000000, 4.000000, 1000.000000, 0.000000, 0.000000) = -250.000000",
+        "amortization(1000.000000, 0.050000, 2.000000) = 2 payments of 537.80",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
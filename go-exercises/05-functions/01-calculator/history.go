@@ -0,0 +1,195 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+)
+
+// HistoryEntry is a single recorded calculation
+type HistoryEntry struct {
+    Operator    string
+    Operands    []float64
+    OperandText []string // exact operands when Operands is only an approximation
+    Expression  string   // set instead of Operands for Evaluate entries
+    Result      float64
+    ResultText  string // exact result when Result is only an approximation
+    Err         error
+    Timestamp   time.Time
+}
+
+// String formats the entry the way GetHistory always has, e.g. "2.000000 + 3.000000 = 5.000000"
+func (e HistoryEntry) String() string {
+    operands := e.OperandText
+    if operands == nil {
+        operands = make([]string, len(e.Operands))
+        for i, operand := range e.Operands {
+            operands[i] = fmt.Sprintf("%f", operand)
+        }
+    }
+    var lhs string
+    switch {
+    case e.Expression != "":
+        lhs = e.Expression
+    case len(operands) == 2 && isInfix(e.Operator):
+        lhs = fmt.Sprintf("%s %s %s", operands[0], e.Operator, operands[1])
+    default:
+        lhs = fmt.Sprintf("%s(%s)", e.Operator, strings.Join(operands, ", "))
+    }
+    if e.Err != nil {
+        return fmt.Sprintf("%s = error: %v", lhs, e.Err)
+    }
+    if e.ResultText != "" {
+        return fmt.Sprintf("%s = %s", lhs, e.ResultText)
+    }
+    return fmt.Sprintf("%s = %f", lhs, e.Result)
+}
+
+// historyEntryJSON is the wire form of HistoryEntry; errors travel as their message
+type historyEntryJSON struct {
+    Operator    string    `json:"operator"`
+    Operands    []float64 `json:"operands,omitempty"`
+    OperandText []string  `json:"operandText,omitempty"`
+    Expression  string    `json:"expression,omitempty"`
+    Result      float64   `json:"result"`
+    ResultText  string    `json:"resultText,omitempty"`
+    Error       string    `json:"error,omitempty"`
+    Timestamp   time.Time `json:"timestamp"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (e HistoryEntry) MarshalJSON() ([]byte, error) {
+    wire := historyEntryJSON{
+        Operator:    e.Operator,
+        Operands:    e.Operands,
+        OperandText: e.OperandText,
+        Expression:  e.Expression,
+        Result:      e.Result,
+        ResultText:  e.ResultText,
+        Timestamp:   e.Timestamp,
+    }
+    if e.Err != nil {
+        wire.Error = e.Err.Error()
+    }
+    return json.Marshal(wire)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (e *HistoryEntry) UnmarshalJSON(data []byte) error {
+    var wire historyEntryJSON
+    if err := json.Unmarshal(data, &wire); err != nil {
+        return err
+    }
+    *e = HistoryEntry{
+        Operator:    wire.Operator,
+        Operands:    wire.Operands,
+        OperandText: wire.OperandText,
+        Expression:  wire.Expression,
+        Result:      wire.Result,
+        ResultText:  wire.ResultText,
+        Timestamp:   wire.Timestamp,
+    }
+    if wire.Error != "" {
+        e.Err = restoreError(wire.Error)
+    }
+    return nil
+}
+
+// History is an ordered list of entries with query helpers
+type History []HistoryEntry
+
+// ByOperator returns the entries recorded for the given operator, e.g. "+" or "eval"
+func (h History) ByOperator(op string) History {
+    var matched History
+    for _, entry := range h {
+        if entry.Operator == op {
+            matched = append(matched, entry)
+        }
+    }
+    return matched
+}
+
+// Between returns the entries recorded in [from, to)
+func (h History) Between(from, to time.Time) History {
+    var matched History
+    for _, entry := range h {
+        if !entry.Timestamp.Before(from) && entry.Timestamp.Before(to) {
+            matched = append(matched, entry)
+        }
+    }
+    return matched
+}
+
+// Last returns the n most recent entries, or all of them if there are fewer
+func (h History) Last(n int) History {
+    if n <= 0 {
+        return History{}
+    }
+    if n > len(h) {
+        n = len(h)
+    }
+    return append(History{}, h[len(h)-n:]...)
+}
+
+// Successful returns the entries that completed without an error
+func (h History) Successful() History {
+    var matched History
+    for _, entry := range h {
+        if entry.Err == nil {
+            matched = append(matched, entry)
+        }
+    }
+    return matched
+}
+
+// Strings formats every entry with HistoryEntry.String
+func (h History) Strings() []string {
+    lines := make([]string, len(h))
+    for i, entry := range h {
+        lines[i] = entry.String()
+    }
+    return lines
+}
+
+// History returns a copy of every recorded entry, including failed operations
+func (c *Calculator) History() History {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return append(History{}, c.history...)
+}
+
+// ClearHistory removes all recorded entries
+func (c *Calculator) ClearHistory() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.history = make([]HistoryEntry, 0)
+}
+
+// ExportHistory writes the history to w as a JSON array
+func (c *Calculator) ExportHistory(w io.Writer) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(c.History())
+}
+
+// ImportHistory replaces the history with a JSON array previously written by ExportHistory
+func (c *Calculator) ImportHistory(r io.Reader) error {
+    var entries History
+    if err := json.NewDecoder(r).Decode(&entries); err != nil {
+        return fmt.Errorf("import history: %w", err)
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.history = append(make([]HistoryEntry, 0, len(entries)), entries...)
+    return nil
+}
+
+// record timestamps an entry and appends it to the history
+func (c *Calculator) record(entry HistoryEntry) {
+    entry.Timestamp = c.now()
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.history = append(c.history, entry)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+)
+
+// newTestCalculator returns a calculator whose clock advances one second per entry
+func newTestCalculator(start time.Time) *Calculator {
+    calc := NewCalculator()
+    current := start
+    calc.now = func() time.Time {
+        current = current.Add(time.Second)
+        return current
+    }
+    return calc
+}
+
+func TestHistoryEntries(t *testing.T) {
+    calc := NewCalculator()
+    calc.Add(2, 3)
+    calc.Divide(1, 0)
+    calc.Evaluate("2 * 4")
+
+    history := calc.History()
+    if len(history) != 3 {
+        t.Fatalf("expected 3 entries, got %d", len(history))
+    }
+    add := history[0]
+    if add.Operator != "+" || len(add.Operands) != 2 || add.Operands[0] != 2 || add.Operands[1] != 3 || add.Result != 5 || add.Err != nil {
+        t.Errorf("unexpected add entry: %+v", add)
+    }
+    if history[1].Err == nil {
+        t.Error("expected division by zero to be recorded with its error")
+    }
+    if history[2].Operator != "eval" || history[2].Expression != "2 * 4" || history[2].Result != 8 {
+        t.Errorf("unexpected eval entry: %+v", history[2])
+    }
+    for _, entry := range history {
+        if entry.Timestamp.IsZero() {
+            t.Errorf("entry %v has no timestamp", entry)
+        }
+    }
+
+    // failed operations stay out of the string view
+    if got := calc.GetHistory(); len(got) != 2 {
+        t.Errorf("expected 2 formatted entries, got %v", got)
+    }
+
+    // the returned history is a copy
+    history[0].Result = 100
+    if calc.History()[0].Result != 5 {
+        t.Error("History should return a copy of the entries")
+    }
+}
+
+func TestHistoryQueries(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    calc := newTestCalculator(start)
+    calc.Add(1, 1)      // start+1s
+    calc.Multiply(2, 2) // start+2s
+    calc.Add(3, 3)      // start+3s
+    calc.Subtract(4, 4) // start+4s
+
+    history := calc.History()
+
+    if got := history.ByOperator("+"); len(got) != 2 || got[0].Result != 2 || got[1].Result != 6 {
+        t.Errorf("ByOperator(\"+\") = %v", got)
+    }
+    if got := history.ByOperator("/"); len(got) != 0 {
+        t.Errorf("ByOperator(\"/\") = %v, want none", got)
+    }
+    if got := history.Between(start.Add(2*time.Second), start.Add(4*time.Second)); len(got) != 2 || got[0].Operator != "*" || got[1].Operator != "+" {
+        t.Errorf("Between = %v", got)
+    }
+    if got := history.Last(2); len(got) != 2 || got[0].Result != 6 || got[1].Result != 0 {
+        t.Errorf("Last(2) = %v", got)
+    }
+    if got := history.Last(10); len(got) != 4 {
+        t.Errorf("Last(10) returned %d entries, want 4", len(got))
+    }
+    if got := history.Last(0); len(got) != 0 {
+        t.Errorf("Last(0) returned %d entries, want 0", len(got))
+    }
+}
+
+func TestHistoryEntryString(t *testing.T) {
+    tests := []struct {
+        name     string
+        entry    HistoryEntry
+        expected string
+    }{
+        {"binary", HistoryEntry{Operator: "/", Operands: []float64{6, 2}, Result: 3}, "6.000000 / 2.000000 = 3.000000"},
+        {"expression", HistoryEntry{Operator: "eval", Expression: "1 + 2", Result: 3}, "1 + 2 = 3.000000"},
+        {"failed", HistoryEntry{Operator: "/", Operands: []float64{1, 0}, Err: errors.New("division by zero")}, "1.000000 / 0.000000 = error: division by zero"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.entry.String(); got != tt.expected {
+                t.Errorf("String() = %q, want %q", got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestHistoryExportImport(t *testing.T) {
+    calc := newTestCalculator(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+    calc.Add(2, 3)
+    calc.Divide(1, 0)
+    calc.Evaluate("(1 + 2) * 3")
+
+    var buf bytes.Buffer
+    if err := calc.ExportHistory(&buf); err != nil {
+        t.Fatalf("ExportHistory failed: %v", err)
+    }
+
+    restored := NewCalculator()
+    if err := restored.ImportHistory(&buf); err != nil {
+        t.Fatalf("ImportHistory failed: %v", err)
+    }
+
+    original, imported := calc.History(), restored.History()
+    if len(imported) != len(original) {
+        t.Fatalf("imported %d entries, want %d", len(imported), len(original))
+    }
+    for i := range original {
+        if imported[i].String() != original[i].String() {
+            t.Errorf("entry %d: got %q, want %q", i, imported[i], original[i])
+        }
+        if !imported[i].Timestamp.Equal(original[i].Timestamp) {
+            t.Errorf("entry %d: timestamp %v, want %v", i, imported[i].Timestamp, original[i].Timestamp)
+        }
+    }
+    if imported[1].Err == nil || imported[1].Err.Error() != original[1].Err.Error() {
+        t.Errorf("error not restored: %v", imported[1].Err)
+    }
+
+    if err := restored.ImportHistory(strings.NewReader("not json")); err == nil {
+        t.Error("expected error importing invalid JSON")
+    }
+}
+
+func TestClearHistory(t *testing.T) {
+    calc := NewCalculator()
+    calc.Add(1, 2)
+    calc.ClearHistory()
+    if len(calc.History()) != 0 {
+        t.Error("expected empty history after ClearHistory")
+    }
+}
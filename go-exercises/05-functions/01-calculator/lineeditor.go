@@ -0,0 +1,185 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Keys the line editor understands besides printable characters
+const (
+    keyCtrlA     = 1
+    keyCtrlB     = 2
+    keyCtrlC     = 3
+    keyCtrlD     = 4
+    keyCtrlE     = 5
+    keyCtrlF     = 6
+    keyBackspace = 8
+    keyCtrlK     = 11
+    keyCtrlN     = 14
+    keyCtrlP     = 16
+    keyCtrlU     = 21
+    keyCtrlW     = 23
+    keyEscape    = 27
+    keyDelete    = 127
+)
+
+// lineEditor reads lines from a terminal in raw mode. It supports moving the cursor,
+// deleting characters and words, and recalling earlier lines with the up and down arrows.
+type lineEditor struct {
+    in      *bufio.Reader
+    out     io.Writer
+    history []string
+
+    line   []rune
+    cursor int
+    recall int    // index into history of the line being shown
+    draft  []rune // the unfinished line, kept while browsing history
+}
+
+func newLineEditor(in io.Reader, out io.Writer, history []string) *lineEditor {
+    return &lineEditor{in: bufio.NewReader(in), out: out, history: history}
+}
+
+// readLine shows prompt and returns the next line, without its terminator.
+// Ctrl-D on an empty line returns io.EOF; Ctrl-C discards the line.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+    e.line, e.cursor, e.recall, e.draft = nil, 0, len(e.history), nil
+    fmt.Fprint(e.out, prompt)
+    for {
+        key, _, err := e.in.ReadRune()
+        if err != nil {
+            return "", err
+        }
+        switch key {
+        case '\r', '\n':
+            fmt.Fprint(e.out, "\r\n")
+            line := string(e.line)
+            if strings.TrimSpace(line) != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != line) {
+                e.history = append(e.history, line)
+            }
+            return line, nil
+        case keyCtrlC:
+            fmt.Fprint(e.out, "^C\r\n")
+            return "", nil
+        case keyCtrlD:
+            if len(e.line) == 0 {
+                return "", io.EOF
+            }
+            e.deleteAt(e.cursor)
+        case keyBackspace, keyDelete:
+            if e.cursor > 0 {
+                e.cursor--
+                e.deleteAt(e.cursor)
+            }
+        case keyCtrlA:
+            e.cursor = 0
+        case keyCtrlE:
+            e.cursor = len(e.line)
+        case keyCtrlB:
+            e.cursor = max(e.cursor-1, 0)
+        case keyCtrlF:
+            e.cursor = min(e.cursor+1, len(e.line))
+        case keyCtrlK:
+            e.line = e.line[:e.cursor]
+        case keyCtrlU:
+            e.line = append([]rune(nil), e.line[e.cursor:]...)
+            e.cursor = 0
+        case keyCtrlW:
+            e.deleteWord()
+        case keyCtrlP:
+            e.browse(-1)
+        case keyCtrlN:
+            e.browse(1)
+        case keyEscape:
+            if err := e.escape(); err != nil {
+                return "", err
+            }
+        default:
+            if key < ' ' {
+                continue
+            }
+            e.line = append(e.line[:e.cursor], append([]rune{key}, e.line[e.cursor:]...)...)
+            e.cursor++
+        }
+        e.redraw(prompt)
+    }
+}
+
+// escape handles the ANSI sequences sent by the arrow, Home, End and Delete keys
+func (e *lineEditor) escape() error {
+    if next, _, err := e.in.ReadRune(); err != nil || (next != '[' && next != 'O') {
+        return err
+    }
+    code, _, err := e.in.ReadRune()
+    if err != nil {
+        return err
+    }
+    switch code {
+    case 'A':
+        e.browse(-1)
+    case 'B':
+        e.browse(1)
+    case 'C':
+        e.cursor = min(e.cursor+1, len(e.line))
+    case 'D':
+        e.cursor = max(e.cursor-1, 0)
+    case 'H':
+        e.cursor = 0
+    case 'F':
+        e.cursor = len(e.line)
+    case '3':
+        if tilde, _, err := e.in.ReadRune(); err != nil || tilde != '~' {
+            return err
+        }
+        e.deleteAt(e.cursor)
+    }
+    return nil
+}
+
+// browse replaces the line with an older (-1) or newer (+1) history entry
+func (e *lineEditor) browse(step int) {
+    next := e.recall + step
+    if next < 0 || next > len(e.history) {
+        return
+    }
+    if e.recall == len(e.history) {
+        e.draft = e.line
+    }
+    e.recall = next
+    if next == len(e.history) {
+        e.line = e.draft
+    } else {
+        e.line = []rune(e.history[next])
+    }
+    e.cursor = len(e.line)
+}
+
+func (e *lineEditor) deleteAt(i int) {
+    if i < len(e.line) {
+        e.line = append(e.line[:i], e.line[i+1:]...)
+    }
+}
+
+// deleteWord removes the word before the cursor and the spaces after it
+func (e *lineEditor) deleteWord() {
+    start := e.cursor
+    for start > 0 && e.line[start-1] == ' ' {
+        start--
+    }
+    for start > 0 && e.line[start-1] != ' ' {
+        start--
+    }
+    e.line = append(e.line[:start], e.line[e.cursor:]...)
+    e.cursor = start
+}
+
+// redraw rewrites the prompt and line, clears what is left of the old line and
+// moves the terminal cursor back to the editing position
+func (e *lineEditor) redraw(prompt string) {
+    fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(e.line))
+    if back := len(e.line) - e.cursor; back > 0 {
+        fmt.Fprintf(e.out, "\x1b[%dD", back)
+    }
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+    "bytes"
+    "io"
+    "strings"
+    "testing"
+)
+
+func TestLineEditor(t *testing.T) {
+    tests := []struct {
+        name     string
+        keys     string
+        expected []string
+    }{
+        {"plain lines", "1 + 2\r3\n", []string{"1 + 2", "3"}},
+        {"backspace", "12\x7f3\r", []string{"13"}},
+        {"insert after moving left", "abc\x1b[D\x1b[DX\r", []string{"aXbc"}},
+        {"home and end", "bc\x01a\x05d\r", []string{"abcd"}},
+        {"delete under cursor", "abc\x1b[H\x1b[3~\r", []string{"bc"}},
+        {"kill to start", "abc def\x1b[D\x15\r", []string{"f"}},
+        {"kill to end", "abc def\x01\x06\x0b\r", []string{"a"}},
+        {"delete word", "sqrt(2) + ans  \x17\r", []string{"sqrt(2) + "}},
+        {"ctrl-c discards the line", "oops\x03ok\r", []string{"", "ok"}},
+        {"recall previous lines", "1 + 1\r2 + 2\r\x1b[A\x1b[A\r", []string{"1 + 1", "2 + 2", "1 + 1"}},
+        {"edit a recalled line", "x = 3\r\x1b[A\x7f4\r", []string{"x = 3", "x = 4"}},
+        {"back to the draft", "old\rnew\x10\x0e\r", []string{"old", "new"}},
+        {"history stops at the oldest line", "a\r\x1b[A\x1b[A\x1b[Ab\r", []string{"a", "ab"}},
+        {"ignore control keys", "a\x07b\r", []string{"ab"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var out bytes.Buffer
+            editor := newLineEditor(strings.NewReader(tt.keys), &out, nil)
+            var lines []string
+            for {
+                line, err := editor.readLine("> ")
+                if err == io.EOF {
+                    break
+                }
+                if err != nil {
+                    t.Fatalf("readLine failed: %v", err)
+                }
+                lines = append(lines, line)
+            }
+            if strings.Join(lines, "|") != strings.Join(tt.expected, "|") {
+                t.Errorf("got %q, want %q", lines, tt.expected)
+            }
+        })
+    }
+}
+
+func TestLineEditorEOF(t *testing.T) {
+    var out bytes.Buffer
+    editor := newLineEditor(strings.NewReader("ab\x04\x01\x04\r\x04"), &out, []string{"earlier"})
+    if line, err := editor.readLine("> "); err != nil || line != "b" {
+        t.Errorf("Ctrl-D inside a line should delete, got %q, %v", line, err)
+    }
+    if _, err := editor.readLine("> "); err != io.EOF {
+        t.Errorf("Ctrl-D on an empty line: expected io.EOF, got %v", err)
+    }
+    if len(editor.history) != 2 || editor.history[1] != "b" {
+        t.Errorf("unexpected history %q", editor.history)
+    }
+    if !strings.HasPrefix(out.String(), "> ") || !strings.Contains(out.String(), "\r> b\x1b[K") {
+        t.Errorf("unexpected terminal output %q", out.String())
+    }
+}
@@ -0,0 +1,48 @@
+// The HTTP server relies on Go 1.22 method and wildcard patterns, which are off by default
+// when the package is built without a go.mod.
+
+//go:debug httpmuxgo121=0
+package main
+
+import (
+    "flag"
+    "fmt"
+    "net/http"
+    "os"
+)
+
+func main() {
+    file := flag.String("f", "", "evaluate the expressions in `file` and exit, failing on the first error")
+    addr := flag.String("http", "", "serve the JSON API on `address`, e.g. :8080, instead of starting the REPL")
+    flag.Parse()
+
+    if *addr != "" {
+        fmt.Printf("calculator: serving on %s\n", *addr)
+        if err := http.ListenAndServe(*addr, NewServer(nil)); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    repl := NewREPL()
+    if *file != "" {
+        f, err := os.Open(*file)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        defer f.Close()
+        if err := repl.Run(f, os.Stdout, false); err != nil {
+            fmt.Fprintf(os.Stderr, "%s: %v\n", *file, err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    fmt.Println("calculator: type help for commands, quit to exit")
+    if err := repl.Run(os.Stdin, os.Stdout, true); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+}
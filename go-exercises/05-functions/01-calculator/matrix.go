@@ -0,0 +1,379 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "strings"
+)
+
+// ErrSingularMatrix is returned when a matrix has no inverse
+var ErrSingularMatrix = errors.New("invalid operation: singular matrix")
+
+// singularTolerance is the pivot size, relative to the largest entry, below which
+// a matrix is treated as singular
+const singularTolerance = 1e-12
+
+// Matrix is an immutable rows x cols matrix of float64 values
+type Matrix struct {
+    rows, cols int
+    data       []float64 // row-major
+}
+
+// NewMatrix copies rows into a matrix; every row must have the same, non-zero length
+func NewMatrix(rows [][]float64) (Matrix, error) {
+    if len(rows) == 0 || len(rows[0]) == 0 {
+        return Matrix{}, fmt.Errorf("%w: matrix needs at least one row and column", ErrDimensionMismatch)
+    }
+    m := zeros(len(rows), len(rows[0]))
+    for i, row := range rows {
+        if len(row) != m.cols {
+            return Matrix{}, fmt.Errorf("%w: row %d has %d values, want %d", ErrDimensionMismatch, i, len(row), m.cols)
+        }
+        copy(m.data[i*m.cols:], row)
+    }
+    return m, nil
+}
+
+// Zero returns a rows x cols matrix of zeros; both sizes must be positive
+func Zero(rows, cols int) (Matrix, error) {
+    if rows < 1 || cols < 1 {
+        return Matrix{}, fmt.Errorf("%w: cannot make a %dx%d matrix", ErrDimensionMismatch, rows, cols)
+    }
+    return zeros(rows, cols), nil
+}
+
+// Identity returns the n x n identity matrix; n must be positive
+func Identity(n int) (Matrix, error) {
+    if n < 1 {
+        return Matrix{}, fmt.Errorf("%w: cannot make a %dx%d matrix", ErrDimensionMismatch, n, n)
+    }
+    return identity(n), nil
+}
+
+// zeros is Zero for sizes known to be valid
+func zeros(rows, cols int) Matrix {
+    return Matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+func identity(n int) Matrix {
+    m := zeros(n, n)
+    for i := 0; i < n; i++ {
+        m.set(i, i, 1)
+    }
+    return m
+}
+
+// Rows returns the number of rows
+func (m Matrix) Rows() int {
+    return m.rows
+}
+
+// Cols returns the number of columns
+func (m Matrix) Cols() int {
+    return m.cols
+}
+
+// At returns the entry in row i and column j, counting from zero
+func (m Matrix) At(i, j int) float64 {
+    return m.data[i*m.cols+j]
+}
+
+// Slice returns a copy of the entries as rows
+func (m Matrix) Slice() [][]float64 {
+    rows := make([][]float64, m.rows)
+    for i := range rows {
+        rows[i] = append([]float64(nil), m.data[i*m.cols:(i+1)*m.cols]...)
+    }
+    return rows
+}
+
+// String formats the matrix as "[[1 2] [3 4]]"
+func (m Matrix) String() string {
+    rows := make([]string, m.rows)
+    for i := range rows {
+        values := make([]string, m.cols)
+        for j := range values {
+            values[j] = formatFloat(m.At(i, j))
+        }
+        rows[i] = "[" + strings.Join(values, " ") + "]"
+    }
+    return "[" + strings.Join(rows, " ") + "]"
+}
+
+// dims is the compact form used in the history, e.g. "[2x3]"
+func (m Matrix) dims() string {
+    return fmt.Sprintf("[%dx%d]", m.rows, m.cols)
+}
+
+func (m Matrix) set(i, j int, v float64) {
+    m.data[i*m.cols+j] = v
+}
+
+// MatrixCalculator performs matrix operations and records them in the parent
+// Calculator's history by shape, e.g. "[2x3] * [3x2] = [2x2]"
+type MatrixCalculator struct {
+    calc *Calculator
+}
+
+// Matrix returns a matrix view of the calculator
+func (c *Calculator) Matrix() *MatrixCalculator {
+    return &MatrixCalculator{calc: c}
+}
+
+// Add adds two matrices of the same shape
+func (mc *MatrixCalculator) Add(a, b Matrix) (Matrix, error) {
+    return mc.apply("add", "+", []Matrix{a, b}, nil, false, func() (Matrix, error) {
+        return elementwise(a, b, func(x, y float64) float64 { return x + y })
+    })
+}
+
+// Subtract subtracts b from a; both must have the same shape
+func (mc *MatrixCalculator) Subtract(a, b Matrix) (Matrix, error) {
+    return mc.apply("subtract", "-", []Matrix{a, b}, nil, false, func() (Matrix, error) {
+        return elementwise(a, b, func(x, y float64) float64 { return x - y })
+    })
+}
+
+// Multiply returns the matrix product a*b; a must have as many columns as b has rows
+func (mc *MatrixCalculator) Multiply(a, b Matrix) (Matrix, error) {
+    return mc.apply("multiply", "*", []Matrix{a, b}, nil, false, func() (Matrix, error) {
+        if a.cols != b.rows {
+            return Matrix{}, fmt.Errorf("%w: %s * %s", ErrDimensionMismatch, a.dims(), b.dims())
+        }
+        product := zeros(a.rows, b.cols)
+        for i := 0; i < a.rows; i++ {
+            for k := 0; k < a.cols; k++ {
+                aik := a.At(i, k)
+                for j := 0; j < b.cols; j++ {
+                    product.data[i*b.cols+j] += aik * b.At(k, j)
+                }
+            }
+        }
+        return product, nil
+    })
+}
+
+// Scale multiplies every entry by k
+func (mc *MatrixCalculator) Scale(m Matrix, k float64) (Matrix, error) {
+    return mc.apply("scale", "scale", []Matrix{m}, []float64{k}, false, func() (Matrix, error) {
+        scaled := zeros(m.rows, m.cols)
+        for i, v := range m.data {
+            scaled.data[i] = v * k
+        }
+        return scaled, nil
+    })
+}
+
+// Transpose swaps rows and columns
+func (mc *MatrixCalculator) Transpose(m Matrix) (Matrix, error) {
+    return mc.apply("transpose", "transpose", []Matrix{m}, nil, false, func() (Matrix, error) {
+        t := zeros(m.cols, m.rows)
+        for i := 0; i < m.rows; i++ {
+            for j := 0; j < m.cols; j++ {
+                t.set(j, i, m.At(i, j))
+            }
+        }
+        return t, nil
+    })
+}
+
+// Determinant returns the determinant of a square matrix, the product of its LU
+// pivots. Unlike Inverse and Solve it applies no tolerance, so it is 0 only
+// when a pivot is exactly zero.
+func (mc *MatrixCalculator) Determinant(m Matrix) (float64, error) {
+    result, err := mc.apply("det", "det", []Matrix{m}, nil, true, func() (Matrix, error) {
+        lu, err := decompose(m, 0)
+        if errors.Is(err, ErrSingularMatrix) {
+            return zeros(1, 1), nil
+        }
+        if err != nil {
+            return Matrix{}, err
+        }
+        return Matrix{rows: 1, cols: 1, data: []float64{lu.determinant()}}, nil
+    })
+    if err != nil {
+        return 0, err
+    }
+    return result.At(0, 0), nil
+}
+
+// Inverse returns the inverse of a square matrix, or ErrSingularMatrix
+func (mc *MatrixCalculator) Inverse(m Matrix) (Matrix, error) {
+    return mc.apply("inverse", "inverse", []Matrix{m}, nil, false, func() (Matrix, error) {
+        lu, err := decompose(m, singularTolerance)
+        if err != nil {
+            return Matrix{}, err
+        }
+        return lu.solve(identity(m.rows)), nil
+    })
+}
+
+// Solve returns x with a*x = b for a square, non-singular a. b may hold several
+// right-hand sides as columns.
+func (mc *MatrixCalculator) Solve(a, b Matrix) (Matrix, error) {
+    return mc.apply("solve", "solve", []Matrix{a, b}, nil, false, func() (Matrix, error) {
+        if b.rows != a.rows {
+            return Matrix{}, fmt.Errorf("%w: cannot solve %s x = %s", ErrDimensionMismatch, a.dims(), b.dims())
+        }
+        lu, err := decompose(a, singularTolerance)
+        if err != nil {
+            return Matrix{}, err
+        }
+        return lu.solve(b), nil
+    })
+}
+
+// apply validates the operands, runs fn and records the operation by shape.
+// A scalar result is returned by fn as a 1x1 matrix and recorded as a number.
+func (mc *MatrixCalculator) apply(name, symbol string, operands []Matrix, args []float64, scalar bool, fn func() (Matrix, error)) (Matrix, error) {
+    entry := HistoryEntry{Operator: symbol, Operands: args}
+    for _, m := range operands {
+        entry.OperandText = append(entry.OperandText, m.dims())
+    }
+    for _, arg := range args {
+        entry.OperandText = append(entry.OperandText, formatFloat(arg))
+    }
+
+    err := checkMatrices(operands, args)
+    var result Matrix
+    if err == nil {
+        result, err = fn()
+    }
+    if err == nil {
+        for _, v := range result.data {
+            if math.IsInf(v, 0) || math.IsNaN(v) {
+                err = ErrOverflow
+                break
+            }
+        }
+    }
+    if err != nil {
+        entry.Err = &CalcError{Op: name, Operands: args, OperandText: entry.OperandText, Err: err}
+        mc.calc.record(entry)
+        return Matrix{}, entry.Err
+    }
+    if scalar {
+        entry.Result = result.At(0, 0)
+        entry.ResultText = formatFloat(entry.Result)
+    } else {
+        entry.ResultText = result.dims()
+    }
+    mc.calc.record(entry)
+    return result, nil
+}
+
+// checkMatrices applies the NaN and infinity checks of the scalar operators
+func checkMatrices(operands []Matrix, args []float64) error {
+    values := append([]float64(nil), args...)
+    for _, m := range operands {
+        values = append(values, m.data...)
+    }
+    for _, v := range values {
+        if math.IsNaN(v) {
+            return ErrNaN
+        }
+    }
+    for _, v := range values {
+        if math.IsInf(v, 0) {
+            return ErrInfinite
+        }
+    }
+    return nil
+}
+
+func elementwise(a, b Matrix, fn func(x, y float64) float64) (Matrix, error) {
+    if a.rows != b.rows || a.cols != b.cols {
+        return Matrix{}, fmt.Errorf("%w: %s and %s", ErrDimensionMismatch, a.dims(), b.dims())
+    }
+    result := zeros(a.rows, a.cols)
+    for i := range result.data {
+        result.data[i] = fn(a.data[i], b.data[i])
+    }
+    return result, nil
+}
+
+// luDecomposition holds PA = LU with unit-diagonal L and U packed into one matrix
+type luDecomposition struct {
+    lu   Matrix
+    perm []int   // row i of PA is row perm[i] of A
+    sign float64 // determinant of P
+}
+
+// decompose factors a square matrix with partial pivoting. It fails with
+// ErrSingularMatrix when a pivot is at most tolerance times the largest entry.
+func decompose(m Matrix, tolerance float64) (luDecomposition, error) {
+    if m.rows != m.cols {
+        return luDecomposition{}, fmt.Errorf("%w: %s is not square", ErrDimensionMismatch, m.dims())
+    }
+    n := m.rows
+    lu := Matrix{rows: n, cols: n, data: append([]float64(nil), m.data...)}
+    perm := make([]int, n)
+    for i := range perm {
+        perm[i] = i
+    }
+    scale := 0.0
+    for _, v := range m.data {
+        scale = math.Max(scale, math.Abs(v))
+    }
+    sign := 1.0
+    for k := 0; k < n; k++ {
+        pivot := k
+        for i := k + 1; i < n; i++ {
+            if math.Abs(lu.At(i, k)) > math.Abs(lu.At(pivot, k)) {
+                pivot = i
+            }
+        }
+        if math.Abs(lu.At(pivot, k)) <= tolerance*scale {
+            return luDecomposition{}, ErrSingularMatrix
+        }
+        if pivot != k {
+            for j := 0; j < n; j++ {
+                a, b := lu.At(k, j), lu.At(pivot, j)
+                lu.set(k, j, b)
+                lu.set(pivot, j, a)
+            }
+            perm[k], perm[pivot] = perm[pivot], perm[k]
+            sign = -sign
+        }
+        for i := k + 1; i < n; i++ {
+            factor := lu.At(i, k) / lu.At(k, k)
+            lu.set(i, k, factor)
+            for j := k + 1; j < n; j++ {
+                lu.set(i, j, lu.At(i, j)-factor*lu.At(k, j))
+            }
+        }
+    }
+    return luDecomposition{lu: lu, perm: perm, sign: sign}, nil
+}
+
+func (d luDecomposition) determinant() float64 {
+    det := d.sign
+    for i := 0; i < d.lu.rows; i++ {
+        det *= d.lu.At(i, i)
+    }
+    return det
+}
+
+// solve finds x with A x = b by forward and back substitution on each column of b
+func (d luDecomposition) solve(b Matrix) Matrix {
+    n := d.lu.rows
+    x := zeros(n, b.cols)
+    for col := 0; col < b.cols; col++ {
+        for i := 0; i < n; i++ {
+            sum := b.At(d.perm[i], col)
+            for j := 0; j < i; j++ {
+                sum -= d.lu.At(i, j) * x.At(j, col)
+            }
+            x.set(i, col, sum)
+        }
+        for i := n - 1; i >= 0; i-- {
+            sum := x.At(i, col)
+            for j := i + 1; j < n; j++ {
+                sum -= d.lu.At(i, j) * x.At(j, col)
+            }
+            x.set(i, col, sum/d.lu.At(i, i))
+        }
+    }
+    return x
+}
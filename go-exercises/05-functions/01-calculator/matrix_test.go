@@ -0,0 +1,221 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+func mustMatrix(t *testing.T, rows ...[]float64) Matrix {
+    t.Helper()
+    m, err := NewMatrix(rows)
+    if err != nil {
+        t.Fatalf("NewMatrix(%v) failed: %v", rows, err)
+    }
+    return m
+}
+
+// mustIdentity returns the n x n identity matrix or fails the test
+func mustIdentity(t *testing.T, n int) Matrix {
+    t.Helper()
+    m, err := Identity(n)
+    if err != nil {
+        t.Fatalf("Identity(%d) failed: %v", n, err)
+    }
+    return m
+}
+
+// assertMatrix compares entries with a small tolerance
+func assertMatrix(t *testing.T, got, want Matrix) {
+    t.Helper()
+    if got.Rows() != want.Rows() || got.Cols() != want.Cols() {
+        t.Fatalf("got %s, want %s", got.dims(), want.dims())
+    }
+    for i := 0; i < got.Rows(); i++ {
+        for j := 0; j < got.Cols(); j++ {
+            if math.Abs(got.At(i, j)-want.At(i, j)) > 1e-12 {
+                t.Fatalf("got %v, want %v", got, want)
+            }
+        }
+    }
+}
+
+func TestNewMatrix(t *testing.T) {
+    m := mustMatrix(t, []float64{1, 2, 3}, []float64{4, 5, 6})
+    if m.Rows() != 2 || m.Cols() != 3 || m.At(1, 2) != 6 {
+        t.Errorf("unexpected matrix %v", m)
+    }
+    if got := m.String(); got != "[[1 2 3] [4 5 6]]" {
+        t.Errorf("String() = %q", got)
+    }
+    rows := m.Slice()
+    rows[0][0] = 99
+    if m.At(0, 0) != 1 {
+        t.Error("Slice exposed the matrix's storage")
+    }
+
+    for _, rows := range [][][]float64{nil, {{}}, {{1, 2}, {3}}} {
+        if _, err := NewMatrix(rows); !errors.Is(err, ErrDimensionMismatch) {
+            t.Errorf("NewMatrix(%v): expected ErrDimensionMismatch, got %v", rows, err)
+        }
+    }
+
+    assertMatrix(t, mustIdentity(t, 2), mustMatrix(t, []float64{1, 0}, []float64{0, 1}))
+    zero, err := Zero(1, 2)
+    if err != nil {
+        t.Fatalf("Zero failed: %v", err)
+    }
+    assertMatrix(t, zero, mustMatrix(t, []float64{0, 0}))
+    for _, size := range [][2]int{{-1, 2}, {2, 0}} {
+        if _, err := Zero(size[0], size[1]); !errors.Is(err, ErrDimensionMismatch) {
+            t.Errorf("Zero(%d, %d): expected ErrDimensionMismatch, got %v", size[0], size[1], err)
+        }
+    }
+    if _, err := Identity(-3); !errors.Is(err, ErrDimensionMismatch) {
+        t.Errorf("Identity(-3): expected ErrDimensionMismatch, got %v", err)
+    }
+}
+
+func TestMatrixArithmetic(t *testing.T) {
+    mc := NewCalculator().Matrix()
+    a := mustMatrix(t, []float64{1, 2}, []float64{3, 4})
+    b := mustMatrix(t, []float64{5, 6}, []float64{7, 8})
+    column := mustMatrix(t, []float64{1}, []float64{1})
+    huge := mustMatrix(t, []float64{1e308, 1e308})
+
+    tests := []struct {
+        name     string
+        op       func() (Matrix, error)
+        expected Matrix
+        wantErr  error
+    }{
+        {"add", func() (Matrix, error) { return mc.Add(a, b) }, mustMatrix(t, []float64{6, 8}, []float64{10, 12}), nil},
+        {"subtract", func() (Matrix, error) { return mc.Subtract(b, a) }, mustMatrix(t, []float64{4, 4}, []float64{4, 4}), nil},
+        {"multiply", func() (Matrix, error) { return mc.Multiply(a, b) }, mustMatrix(t, []float64{19, 22}, []float64{43, 50}), nil},
+        {"matrix times vector", func() (Matrix, error) { return mc.Multiply(a, column) }, mustMatrix(t, []float64{3}, []float64{7}), nil},
+        {"scale", func() (Matrix, error) { return mc.Scale(a, -2) }, mustMatrix(t, []float64{-2, -4}, []float64{-6, -8}), nil},
+        {"transpose", func() (Matrix, error) { return mc.Transpose(column) }, mustMatrix(t, []float64{1, 1}), nil},
+        {"add shape mismatch", func() (Matrix, error) { return mc.Add(a, column) }, Matrix{}, ErrDimensionMismatch},
+        {"multiply shape mismatch", func() (Matrix, error) { return mc.Multiply(column, a) }, Matrix{}, ErrDimensionMismatch},
+        {"NaN entry", func() (Matrix, error) { return mc.Scale(mustMatrix(t, []float64{math.NaN()}), 1) }, Matrix{}, ErrNaN},
+        {"infinite scalar", func() (Matrix, error) { return mc.Scale(a, math.Inf(1)) }, Matrix{}, ErrInfinite},
+        {"overflow", func() (Matrix, error) { return mc.Add(huge, huge) }, Matrix{}, ErrOverflow},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op()
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            assertMatrix(t, result, tt.expected)
+        })
+    }
+}
+
+func TestMatrixLU(t *testing.T) {
+    mc := NewCalculator().Matrix()
+    a := mustMatrix(t, []float64{2, 1, 1}, []float64{4, -6, 0}, []float64{-2, 7, 2})
+    singular := mustMatrix(t, []float64{1, 2}, []float64{2, 4})
+    needsPivot := mustMatrix(t, []float64{0, 1}, []float64{1, 0})
+
+    tests := []struct {
+        name     string
+        m        Matrix
+        expected float64
+        wantErr  error
+    }{
+        {"3x3", a, -16, nil},
+        {"identity", mustIdentity(t, 4), 1, nil},
+        {"tiny pivot", mustMatrix(t, []float64{1, 0}, []float64{0, 1e-13}), 1e-13, nil},
+        {"wide range of pivots", mustMatrix(t, []float64{1e6, 0}, []float64{0, 1e-7}), 0.1, nil},
+        {"row swap flips the sign", needsPivot, -1, nil},
+        {"singular", singular, 0, nil},
+        {"not square", mustMatrix(t, []float64{1, 2}), 0, ErrDimensionMismatch},
+    }
+    for _, tt := range tests {
+        t.Run("det "+tt.name, func(t *testing.T) {
+            det, err := mc.Determinant(tt.m)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil || math.Abs(det-tt.expected) > 1e-12*math.Abs(tt.expected) {
+                t.Errorf("got %v, %v; want %v", det, err, tt.expected)
+            }
+        })
+    }
+
+    inverse, err := mc.Inverse(a)
+    if err != nil {
+        t.Fatalf("Inverse failed: %v", err)
+    }
+    product, _ := mc.Multiply(a, inverse)
+    assertMatrix(t, product, mustIdentity(t, 3))
+    if inverse, err := mc.Inverse(needsPivot); err != nil {
+        t.Errorf("Inverse with pivoting failed: %v", err)
+    } else {
+        assertMatrix(t, inverse, needsPivot)
+    }
+    if _, err := mc.Inverse(singular); !errors.Is(err, ErrSingularMatrix) {
+        t.Errorf("expected ErrSingularMatrix, got %v", err)
+    }
+
+    b := mustMatrix(t, []float64{5}, []float64{-2}, []float64{9})
+    x, err := mc.Solve(a, b)
+    if err != nil {
+        t.Fatalf("Solve failed: %v", err)
+    }
+    assertMatrix(t, x, mustMatrix(t, []float64{1}, []float64{1}, []float64{2}))
+
+    both := mustMatrix(t, []float64{5, 2}, []float64{-2, 4}, []float64{9, -2})
+    x, err = mc.Solve(a, both)
+    if err != nil {
+        t.Fatalf("Solve with two right-hand sides failed: %v", err)
+    }
+    assertMatrix(t, x, mustMatrix(t, []float64{1, 1}, []float64{1, 0}, []float64{2, 0}))
+
+    if _, err := mc.Solve(singular, mustMatrix(t, []float64{1}, []float64{2})); !errors.Is(err, ErrSingularMatrix) {
+        t.Errorf("expected ErrSingularMatrix, got %v", err)
+    }
+    if _, err := mc.Solve(a, mustMatrix(t, []float64{1}, []float64{2})); !errors.Is(err, ErrDimensionMismatch) {
+        t.Errorf("expected ErrDimensionMismatch, got %v", err)
+    }
+}
+
+func TestMatrixHistory(t *testing.T) {
+    calc := NewCalculator()
+    mc := calc.Matrix()
+    a := mustMatrix(t, []float64{1, 2, 3}, []float64{4, 5, 6})
+    mc.Multiply(a, mustIdentity(t, 3))
+    mc.Transpose(a)
+    mc.Scale(a, 2)
+    mc.Determinant(mustIdentity(t, 2))
+    mc.Inverse(zeros(2, 2))
+
+    want := []string{
+        "[2x3] * [3x3] = [2x3]",
+        "transpose([2x3]) = [3x2]",
+        "scale([2x3], 2) = [2x3]",
+        "det([2x2]) = 1",
+        "inverse([2x2]) = error: inverse([2x2]): invalid operation: singular matrix",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+)
+
+// Errors returned by the numerical methods
+var (
+    ErrNoConvergence = errors.New("no convergence")
+    ErrNotBracketed  = errors.New("invalid input: root not bracketed")
+)
+
+// Defaults used for zero SolverOptions fields
+const (
+    DefaultTolerance     = 1e-10
+    DefaultMaxIterations = 100
+)
+
+// SolverOptions controls when a numerical method stops. Zero fields take the defaults.
+type SolverOptions struct {
+    Tolerance     float64
+    MaxIterations int
+}
+
+// withDefaults fills in zero fields and rejects negative ones
+func (o SolverOptions) withDefaults() (SolverOptions, error) {
+    if o.Tolerance == 0 {
+        o.Tolerance = DefaultTolerance
+    }
+    if o.MaxIterations == 0 {
+        o.MaxIterations = DefaultMaxIterations
+    }
+    if !(o.Tolerance > 0) || o.MaxIterations < 0 {
+        return o, fmt.Errorf("%w: tolerance %v and max iterations %d must be positive", ErrDomain, o.Tolerance, o.MaxIterations)
+    }
+    return o, nil
+}
+
+// ConvergenceError reports a method that did not reach its tolerance.
+// errors.Is(err, ErrNoConvergence) matches it.
+type ConvergenceError struct {
+    Method     string
+    Iterations int
+    Estimate   float64 // the best value found
+}
+
+func (e *ConvergenceError) Error() string {
+    return fmt.Sprintf("%v after %d iterations, last estimate %s", ErrNoConvergence, e.Iterations, formatFloat(e.Estimate))
+}
+
+func (e *ConvergenceError) Unwrap() error {
+    return ErrNoConvergence
+}
+
+// Function turns expr into a function of variable for the numerical methods;
+// other variables take their values from vars. Values the calculator rejects,
+// such as ln(0), become NaN and are reported as ErrNaN by the methods.
+func (c *Calculator) Function(expr, variable string, vars map[string]float64) (func(float64) float64, error) {
+    tree, err := Parse(expr)
+    if err != nil {
+        return nil, err
+    }
+    return func(x float64) float64 {
+        scope := make(map[string]float64, len(vars)+1)
+        for name, v := range vars {
+            scope[name] = v
+        }
+        scope[variable] = x
+        v, err := c.eval(tree, scope)
+        if err != nil {
+            return math.NaN()
+        }
+        return v
+    }, nil
+}
+
+// Bisection finds a root of f in [a, b], where f(a) and f(b) have opposite signs,
+// to within Tolerance of the true root
+func (c *Calculator) Bisection(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error) {
+    return c.numeric("bisection", []float64{a, b}, opts, func(opts SolverOptions) (float64, int, error) {
+        fa, err := sample(f, a)
+        if err != nil {
+            return 0, 0, err
+        }
+        fb, err := sample(f, b)
+        if err != nil {
+            return 0, 0, err
+        }
+        switch {
+        case fa == 0:
+            return a, 0, nil
+        case fb == 0:
+            return b, 0, nil
+        case math.Signbit(fa) == math.Signbit(fb):
+            return 0, 0, fmt.Errorf("%w: f(%s) and f(%s) have the same sign", ErrNotBracketed, formatFloat(a), formatFloat(b))
+        }
+        lo, hi := a, b
+        for i := 1; i <= opts.MaxIterations; i++ {
+            mid := lo + (hi-lo)/2
+            fm, err := sample(f, mid)
+            if err != nil {
+                return 0, i, err
+            }
+            if fm == 0 || math.Abs(hi-lo)/2 <= opts.Tolerance {
+                return mid, i, nil
+            }
+            if math.Signbit(fm) == math.Signbit(fa) {
+                lo, fa = mid, fm
+            } else {
+                hi = mid
+            }
+        }
+        return 0, opts.MaxIterations, &ConvergenceError{Method: "bisection", Iterations: opts.MaxIterations, Estimate: lo + (hi-lo)/2}
+    })
+}
+
+// Newton finds a root of f starting from x0, stopping when a step is smaller than
+// Tolerance. A nil df uses the numerical derivative of f.
+func (c *Calculator) Newton(f, df func(float64) float64, x0 float64, opts SolverOptions) (float64, error) {
+    return c.numeric("newton", []float64{x0}, opts, func(opts SolverOptions) (float64, int, error) {
+        return newton(f, df, x0, opts)
+    })
+}
+
+func newton(f, df func(float64) float64, x0 float64, opts SolverOptions) (float64, int, error) {
+    x := x0
+    for i := 1; i <= opts.MaxIterations; i++ {
+        fx, err := sample(f, x)
+        if err != nil {
+            return 0, i, err
+        }
+        if fx == 0 {
+            return x, i, nil
+        }
+        var slope float64
+        if df != nil {
+            slope, err = sample(df, x)
+        } else {
+            slope, _, err = ridders(f, x, SolverOptions{Tolerance: DefaultTolerance, MaxIterations: DefaultMaxIterations})
+        }
+        if err != nil {
+            return 0, i, err
+        }
+        if slope == 0 {
+            return 0, i, fmt.Errorf("%w: zero derivative at %s", ErrDivisionByZero, formatFloat(x))
+        }
+        step := fx / slope
+        x -= step
+        if math.IsInf(x, 0) {
+            return 0, i, ErrOverflow
+        }
+        if math.Abs(step) <= opts.Tolerance {
+            return x, i, nil
+        }
+    }
+    return 0, opts.MaxIterations, &ConvergenceError{Method: "newton", Iterations: opts.MaxIterations, Estimate: x}
+}
+
+// Brent finds a root of f in [a, b], where f(a) and f(b) have opposite signs. It
+// combines bisection with inverse quadratic interpolation, so it is as safe as
+// Bisection but usually much faster.
+func (c *Calculator) Brent(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error) {
+    return c.numeric("brent", []float64{a, b}, opts, func(opts SolverOptions) (float64, int, error) {
+        return brent(f, a, b, opts)
+    })
+}
+
+func brent(f func(float64) float64, a, b float64, opts SolverOptions) (float64, int, error) {
+    fa, err := sample(f, a)
+    if err != nil {
+        return 0, 0, err
+    }
+    fb, err := sample(f, b)
+    if err != nil {
+        return 0, 0, err
+    }
+    switch {
+    case fa == 0:
+        return a, 0, nil
+    case fb == 0:
+        return b, 0, nil
+    case math.Signbit(fa) == math.Signbit(fb):
+        return 0, 0, fmt.Errorf("%w: f(%s) and f(%s) have the same sign", ErrNotBracketed, formatFloat(a), formatFloat(b))
+    }
+    // b is the best estimate, end the other end of the bracket and a the previous b
+    end, fend := b, fb
+    var d, e float64
+    for i := 1; i <= opts.MaxIterations; i++ {
+        if (fb > 0 && fend > 0) || (fb < 0 && fend < 0) {
+            end, fend = a, fa
+            d = b - a
+            e = d
+        }
+        if math.Abs(fend) < math.Abs(fb) {
+            a, b, end = b, end, b
+            fa, fb, fend = fb, fend, fb
+        }
+        tol := 2*epsilon*math.Abs(b) + opts.Tolerance/2
+        half := (end - b) / 2
+        if math.Abs(half) <= tol || fb == 0 {
+            return b, i, nil
+        }
+        if math.Abs(e) >= tol && math.Abs(fa) > math.Abs(fb) {
+            // try interpolation, falling back to bisection when it steps too far
+            var p, q float64
+            s := fb / fa
+            if a == end {
+                p, q = 2*half*s, 1-s
+            } else {
+                r := fb / fend
+                q = fa / fend
+                p = s * (2*half*q*(q-r) - (b-a)*(r-1))
+                q = (q - 1) * (r - 1) * (s - 1)
+            }
+            if p > 0 {
+                q = -q
+            }
+            p = math.Abs(p)
+            if 2*p < math.Min(3*half*q-math.Abs(tol*q), math.Abs(e*q)) {
+                e, d = d, p/q
+            } else {
+                d, e = half, half
+            }
+        } else {
+            d, e = half, half
+        }
+        a, fa = b, fb
+        if math.Abs(d) > tol {
+            b += d
+        } else {
+            b += math.Copysign(tol, half)
+        }
+        if fb, err = sample(f, b); err != nil {
+            return 0, i, err
+        }
+    }
+    return 0, opts.MaxIterations, &ConvergenceError{Method: "brent", Iterations: opts.MaxIterations, Estimate: b}
+}
+
+// epsilon is the spacing of float64 values around 1
+const epsilon = 2.220446049250313e-16
+
+// maxSimpsonDoublings caps the doublings of Simpson, whose cost grows as
+// 2^doublings, at about a million function evaluations
+const maxSimpsonDoublings = 20
+
+// AdaptiveSimpson splits an interval at most maxQuadratureDepth times, about where
+// float64 runs out of distinct midpoints, and gives up after maxQuadratureEvaluations
+// function evaluations, about as many as Simpson's doublings allow
+const (
+    maxQuadratureDepth       = 50
+    maxQuadratureEvaluations = 1 << maxSimpsonDoublings
+)
+
+// Simpson integrates f over [a, b] with the composite Simpson rule, doubling the
+// number of intervals until two estimates differ by at most Tolerance (relative to
+// the result when it is larger than 1). MaxIterations limits the doublings, which
+// never exceed maxSimpsonDoublings.
+func (c *Calculator) Simpson(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error) {
+    return c.numeric("simpson", []float64{a, b}, opts, func(opts SolverOptions) (float64, int, error) {
+        fa, err := sample(f, a)
+        if err != nil {
+            return 0, 0, err
+        }
+        fb, err := sample(f, b)
+        if err != nil {
+            return 0, 0, err
+        }
+        // ends and odd points have weights 1 and 4; the odd points become even
+        // points, weight 2, when the intervals are halved
+        n, h := 2, (b-a)/2
+        ends, even := fa+fb, 0.0
+        odd, err := sample(f, a+h)
+        if err != nil {
+            return 0, 0, err
+        }
+        previous := h / 3 * (ends + 4*odd)
+        doublings := min(opts.MaxIterations, maxSimpsonDoublings)
+        for i := 1; i <= doublings; i++ {
+            n, h = 2*n, h/2
+            even += odd
+            odd = 0
+            for k := 1; k < n; k += 2 {
+                v, err := sample(f, a+float64(k)*h)
+                if err != nil {
+                    return 0, i, err
+                }
+                odd += v
+            }
+            estimate := h / 3 * (ends + 2*even + 4*odd)
+            if math.IsInf(estimate, 0) {
+                return 0, i, ErrOverflow
+            }
+            if math.Abs(estimate-previous) <= opts.Tolerance*math.Max(1, math.Abs(estimate)) {
+                return estimate, i, nil
+            }
+            previous = estimate
+        }
+        return 0, doublings, &ConvergenceError{Method: "simpson", Iterations: doublings, Estimate: previous}
+    })
+}
+
+// AdaptiveSimpson integrates f over [a, b], splitting only the intervals where
+// the Simpson rule has not reached Tolerance. MaxIterations limits how often an
+// interval may be split, never more than maxQuadratureDepth times, and the
+// integration gives up after maxQuadratureEvaluations function evaluations.
+func (c *Calculator) AdaptiveSimpson(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error) {
+    return c.numeric("adaptive simpson", []float64{a, b}, opts, func(opts SolverOptions) (float64, int, error) {
+        q := &quadrature{f: f, maxDepth: min(opts.MaxIterations, maxQuadratureDepth)}
+        fa, fm, fb := q.sample(a), q.sample((a+b)/2), q.sample(b)
+        whole := (b - a) / 6 * (fa + 4*fm + fb)
+        result := q.integrate(a, b, fa, fm, fb, whole, opts.Tolerance, 0)
+        switch {
+        case q.err != nil:
+            return 0, q.depth, q.err
+        case math.IsInf(result, 0):
+            return 0, q.depth, ErrOverflow
+        case q.exhausted:
+            return 0, q.depth, &ConvergenceError{Method: "adaptive simpson", Iterations: q.depth, Estimate: result}
+        }
+        return result, q.depth, nil
+    })
+}
+
+// quadrature holds the state of an adaptive Simpson integration
+type quadrature struct {
+    f         func(float64) float64
+    maxDepth  int
+    depth     int  // deepest split used
+    calls     int  // function evaluations so far
+    exhausted bool // an interval hit maxDepth or the evaluation budget before reaching the tolerance
+    err       error
+}
+
+func (q *quadrature) sample(x float64) float64 {
+    q.calls++
+    v, err := sample(q.f, x)
+    if err != nil && q.err == nil {
+        q.err = err
+    }
+    return v
+}
+
+// integrate refines the Simpson estimate whole of [a, b], whose midpoint is m
+func (q *quadrature) integrate(a, b, fa, fm, fb, whole, tol float64, depth int) float64 {
+    if q.err != nil {
+        return 0
+    }
+    q.depth = max(q.depth, depth)
+    m := (a + b) / 2
+    flm, frm := q.sample((a+m)/2), q.sample((m+b)/2)
+    left := (m - a) / 6 * (fa + 4*flm + fm)
+    right := (b - m) / 6 * (fm + 4*frm + fb)
+    delta := left + right - whole
+    if math.Abs(delta) <= 15*tol {
+        // Richardson extrapolation removes the leading error term
+        return left + right + delta/15
+    }
+    if depth >= q.maxDepth || q.calls >= maxQuadratureEvaluations {
+        q.exhausted = true
+        return left + right + delta/15
+    }
+    return q.integrate(a, m, fa, flm, fm, left, tol/2, depth+1) + q.integrate(m, b, fm, frm, fb, right, tol/2, depth+1)
+}
+
+// NumericDerivative estimates f'(x) by central differences with Richardson
+// extrapolation (Ridders' method), stopping once the error estimate is within
+// Tolerance, relative to the result when it is larger than 1
+func (c *Calculator) NumericDerivative(f func(float64) float64, x float64, opts SolverOptions) (float64, error) {
+    return c.numeric("derivative", []float64{x}, opts, func(opts SolverOptions) (float64, int, error) {
+        return ridders(f, x, opts)
+    })
+}
+
+// ridders shrinks the step by 1.4 each round and extrapolates the central
+// differences to a zero step
+func ridders(f func(float64) float64, x float64, opts SolverOptions) (float64, int, error) {
+    const shrink = 1.4
+    h := 0.1 * math.Max(1, math.Abs(x))
+    central := func(h float64) (float64, error) {
+        right, err := sample(f, x+h)
+        if err != nil {
+            return 0, err
+        }
+        left, err := sample(f, x-h)
+        if err != nil {
+            return 0, err
+        }
+        return (right - left) / (2 * h), nil
+    }
+
+    first, err := central(h)
+    if err != nil {
+        return 0, 0, err
+    }
+    // table[j] holds the j-times extrapolated estimates of the previous round
+    table := []float64{first}
+    best, bestErr := first, math.Inf(1)
+    rounds := 0
+    for i := 1; i <= opts.MaxIterations; i++ {
+        rounds = i
+        h /= shrink
+        estimate, err := central(h)
+        if err != nil {
+            return 0, i, err
+        }
+        row := []float64{estimate}
+        factor := shrink * shrink
+        for j := 1; j <= i; j++ {
+            row = append(row, (row[j-1]*factor-table[j-1])/(factor-1))
+            factor *= shrink * shrink
+            if e := math.Max(math.Abs(row[j]-row[j-1]), math.Abs(row[j]-table[j-1])); e <= bestErr {
+                best, bestErr = row[j], e
+            }
+        }
+        if bestErr <= opts.Tolerance*math.Max(1, math.Abs(best)) {
+            return best, i, nil
+        }
+        if math.Abs(row[i]-table[i-1]) >= 2*bestErr {
+            // higher orders have started to amplify rounding errors
+            break
+        }
+        table = row
+    }
+    return 0, rounds, &ConvergenceError{Method: "derivative", Iterations: rounds, Estimate: best}
+}
+
+// numeric validates the arguments and options, runs fn and records the method
+// as "bisection(f, 0, 2) = 1.4142135623730951 (38 iterations)"
+func (c *Calculator) numeric(method string, args []float64, opts SolverOptions, fn func(opts SolverOptions) (float64, int, error)) (float64, error) {
+    entry := HistoryEntry{Operator: method, Operands: args, OperandText: []string{"f"}}
+    for _, arg := range args {
+        entry.OperandText = append(entry.OperandText, formatFloat(arg))
+    }
+
+    var result float64
+    var iterations int
+    err := checkOperands(args)
+    if err == nil {
+        opts, err = opts.withDefaults()
+    }
+    if err == nil {
+        result, iterations, err = fn(opts)
+    }
+    if err != nil {
+        err = &CalcError{Op: method, Operands: args, OperandText: entry.OperandText, Err: err}
+        entry.Err = err
+        c.record(entry)
+        return 0, err
+    }
+    entry.Result = result
+    entry.ResultText = fmt.Sprintf("%s (%d iterations)", formatFloat(result), iterations)
+    c.record(entry)
+    return result, nil
+}
+
+// checkOperands applies the NaN and infinity checks of the scalar operators
+func checkOperands(args []float64) error {
+    for _, arg := range args {
+        if math.IsNaN(arg) {
+            return ErrNaN
+        }
+    }
+    for _, arg := range args {
+        if math.IsInf(arg, 0) {
+            return ErrInfinite
+        }
+    }
+    return nil
+}
+
+// sample evaluates f, rejecting NaN and infinite values
+func sample(f func(float64) float64, x float64) (float64, error) {
+    v := f(x)
+    switch {
+    case math.IsNaN(v):
+        return 0, fmt.Errorf("%w: f(%s)", ErrNaN, formatFloat(x))
+    case math.IsInf(v, 0):
+        return 0, fmt.Errorf("%w: f(%s)", ErrInfinite, formatFloat(x))
+    }
+    return v, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "math/rand"
+    "testing"
+)
+
+func TestRootFinding(t *testing.T) {
+    calc := NewCalculator()
+    square := func(x float64) float64 { return x*x - 2 }
+    cubic := func(x float64) float64 { return x*x*x - 2*x - 5 }
+    positive := func(x float64) float64 { return x*x + 1 }
+    opts := SolverOptions{Tolerance: 1e-12}
+
+    bracketed := []struct {
+        name  string
+        solve func(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error)
+    }{
+        {"bisection", calc.Bisection},
+        {"brent", calc.Brent},
+    }
+    for _, method := range bracketed {
+        tests := []struct {
+            name     string
+            f        func(float64) float64
+            a, b     float64
+            opts     SolverOptions
+            expected float64
+            wantErr  error
+        }{
+            {"square root of 2", square, 0, 2, opts, math.Sqrt2, nil},
+            {"reversed bracket", square, 2, 0, opts, math.Sqrt2, nil},
+            {"cubic", cubic, 2, 3, opts, 2.0945514815423265, nil},
+            {"root at an end", func(x float64) float64 { return x - 1 }, 1, 3, opts, 1, nil},
+            {"no sign change", positive, -1, 1, opts, 0, ErrNotBracketed},
+            {"too few iterations", square, 0, 2, SolverOptions{Tolerance: 1e-12, MaxIterations: 3}, 0, ErrNoConvergence},
+            {"NaN bound", square, math.NaN(), 2, opts, 0, ErrNaN},
+            {"infinite bound", square, 0, math.Inf(1), opts, 0, ErrInfinite},
+            {"negative tolerance", square, 0, 2, SolverOptions{Tolerance: -1}, 0, ErrDomain},
+            {"function fails", math.Log, -1, 2, opts, 0, ErrNaN},
+        }
+        for _, tt := range tests {
+            t.Run(method.name+" "+tt.name, func(t *testing.T) {
+                root, err := method.solve(tt.f, tt.a, tt.b, tt.opts)
+                if tt.wantErr != nil {
+                    var calcErr *CalcError
+                    if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                        t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                    }
+                    return
+                }
+                if err != nil {
+                    t.Fatalf("unexpected error: %v", err)
+                }
+                if math.Abs(root-tt.expected) > 1e-12 {
+                    t.Errorf("got %v, want %v", root, tt.expected)
+                }
+            })
+        }
+    }
+}
+
+func TestNewton(t *testing.T) {
+    calc := NewCalculator()
+    square := func(x float64) float64 { return x*x - 2 }
+    slope := func(x float64) float64 { return 2 * x }
+
+    if root, err := calc.Newton(square, slope, 1, SolverOptions{}); err != nil || math.Abs(root-math.Sqrt2) > 1e-12 {
+        t.Errorf("Newton with derivative = %v, %v", root, err)
+    }
+    if root, err := calc.Newton(math.Cos, nil, 1, SolverOptions{}); err != nil || math.Abs(root-math.Pi/2) > 1e-12 {
+        t.Errorf("Newton with numerical derivative = %v, %v", root, err)
+    }
+    if _, err := calc.Newton(square, slope, 0, SolverOptions{}); !errors.Is(err, ErrDivisionByZero) {
+        t.Errorf("expected ErrDivisionByZero at a stationary point, got %v", err)
+    }
+
+    // x^(1/3) makes Newton's method overshoot further on every step
+    cubeRoot := func(x float64) float64 { return math.Cbrt(x) }
+    _, err := calc.Newton(cubeRoot, nil, 1, SolverOptions{MaxIterations: 20})
+    var convErr *ConvergenceError
+    if !errors.Is(err, ErrNoConvergence) || !errors.As(err, &convErr) {
+        t.Fatalf("expected *ConvergenceError, got %v", err)
+    }
+    if convErr.Method != "newton" || convErr.Iterations != 20 {
+        t.Errorf("unexpected convergence error %+v", convErr)
+    }
+}
+
+func TestIntegration(t *testing.T) {
+    calc := NewCalculator()
+    methods := []struct {
+        name      string
+        integrate func(f func(float64) float64, a, b float64, opts SolverOptions) (float64, error)
+    }{
+        {"simpson", calc.Simpson},
+        {"adaptive simpson", calc.AdaptiveSimpson},
+    }
+    for _, method := range methods {
+        tests := []struct {
+            name     string
+            f        func(float64) float64
+            a, b     float64
+            expected float64
+            wantErr  error
+        }{
+            {"cubic is exact", func(x float64) float64 { return x * x * x }, 0, 2, 4, nil},
+            {"sine", math.Sin, 0, math.Pi, 2, nil},
+            {"exponential", math.Exp, 0, 1, math.E - 1, nil},
+            {"reversed bounds", math.Exp, 1, 0, 1 - math.E, nil},
+            {"square root", math.Sqrt, 0, 1, 2.0 / 3, nil},
+            {"pole", func(x float64) float64 { return 1 / x }, 0, 1, 0, ErrInfinite},
+            {"NaN bound", math.Sin, 0, math.NaN(), 0, ErrNaN},
+        }
+        for _, tt := range tests {
+            t.Run(method.name+" "+tt.name, func(t *testing.T) {
+                result, err := method.integrate(tt.f, tt.a, tt.b, SolverOptions{Tolerance: 1e-10})
+                if tt.wantErr != nil {
+                    if !errors.Is(err, tt.wantErr) {
+                        t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                    }
+                    return
+                }
+                if err != nil {
+                    t.Fatalf("unexpected error: %v", err)
+                }
+                if math.Abs(result-tt.expected) > 1e-8 {
+                    t.Errorf("got %v, want %v", result, tt.expected)
+                }
+            })
+        }
+    }
+
+    spike := func(x float64) float64 { return 1 / (1e-6 + x*x) }
+    if _, err := calc.AdaptiveSimpson(spike, -1, 1, SolverOptions{MaxIterations: 3}); !errors.Is(err, ErrNoConvergence) {
+        t.Errorf("expected ErrNoConvergence with a shallow split limit, got %v", err)
+    }
+    if _, err := calc.Simpson(spike, -1, 1, SolverOptions{MaxIterations: 3}); !errors.Is(err, ErrNoConvergence) {
+        t.Errorf("expected ErrNoConvergence with few doublings, got %v", err)
+    }
+
+    // a step never converges; the doublings stop long before MaxIterations
+    step := func(x float64) float64 {
+        if x < 1/math.Pi {
+            return 0
+        }
+        return 1
+    }
+    _, err := calc.Simpson(step, 0, 1, SolverOptions{Tolerance: 1e-15})
+    var convErr *ConvergenceError
+    if !errors.As(err, &convErr) || convErr.Iterations != maxSimpsonDoublings {
+        t.Errorf("expected a *ConvergenceError after %d doublings, got %v", maxSimpsonDoublings, err)
+    }
+
+    // noise never settles within a halving tolerance; adaptive splitting stops at its caps
+    rng := rand.New(rand.NewSource(1))
+    calls := 0
+    noisy := func(x float64) float64 {
+        calls++
+        return x + 1e-6*rng.Float64()
+    }
+    _, err = calc.AdaptiveSimpson(noisy, 0, 1, SolverOptions{})
+    if !errors.As(err, &convErr) || convErr.Iterations > maxQuadratureDepth {
+        t.Errorf("expected a *ConvergenceError within %d splits, got %v", maxQuadratureDepth, err)
+    }
+    if calls > maxQuadratureEvaluations+2*maxQuadratureDepth {
+        t.Errorf("%d evaluations, want at most about %d", calls, maxQuadratureEvaluations)
+    }
+}
+
+func TestNumericDerivative(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        f        func(float64) float64
+        x        float64
+        expected float64
+    }{
+        {"sine", math.Sin, 1, math.Cos(1)},
+        {"exponential", math.Exp, 2, math.Exp(2)},
+        {"polynomial", func(x float64) float64 { return x*x*x - x }, -3, 26},
+        {"large x", math.Log, 1e6, 1e-6},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            slope, err := calc.NumericDerivative(tt.f, tt.x, SolverOptions{Tolerance: 1e-9})
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(slope-tt.expected) > 1e-9*math.Max(1, math.Abs(tt.expected)) {
+                t.Errorf("got %v, want %v", slope, tt.expected)
+            }
+        })
+    }
+
+    if _, err := calc.NumericDerivative(math.Abs, 0, SolverOptions{Tolerance: 1e-12}); err != nil {
+        t.Errorf("central differences of |x| at 0 should give 0, got %v", err)
+    }
+    if _, err := calc.NumericDerivative(math.Sqrt, 0, SolverOptions{}); !errors.Is(err, ErrNaN) {
+        t.Errorf("expected ErrNaN outside the domain, got %v", err)
+    }
+}
+
+func TestNumericExpressions(t *testing.T) {
+    calc := NewCalculator()
+    f, err := calc.Function("x ^ 2 - a", "x", map[string]float64{"a": 9})
+    if err != nil {
+        t.Fatalf("Function failed: %v", err)
+    }
+    if root, err := calc.Brent(f, 0, 10, SolverOptions{}); err != nil || math.Abs(root-3) > 1e-10 {
+        t.Errorf("root of x^2 - 9 = %v, %v", root, err)
+    }
+    if area, err := calc.AdaptiveSimpson(f, 0, 3, SolverOptions{}); err != nil || math.Abs(area+18) > 1e-9 {
+        t.Errorf("integral of x^2 - 9 over [0, 3] = %v, %v", area, err)
+    }
+
+    ln, _ := calc.Function("ln(x)", "x", nil)
+    if _, err := calc.Bisection(ln, -1, 2, SolverOptions{}); !errors.Is(err, ErrNaN) {
+        t.Errorf("expected ErrNaN for ln(-1), got %v", err)
+    }
+    if _, err := calc.Function("x +", "x", nil); !errors.Is(err, ErrSyntax) {
+        t.Errorf("expected ErrSyntax, got %v", err)
+    }
+}
+
+func TestNumericHistory(t *testing.T) {
+    calc := NewCalculator()
+    square := func(x float64) float64 { return x*x - 4 }
+    calc.Bisection(square, 0, 4, SolverOptions{})
+    calc.Brent(square, 0, 1, SolverOptions{})
+    calc.Simpson(func(x float64) float64 { return x }, 0, 2, SolverOptions{})
+
+    want := []string{
+        "bisection(f, 0, 4) = 2 (1 iterations)",
+        "brent(f, 0, 1) = error: brent(f, 0, 1): invalid input: root not bracketed: f(0) and f(1) have the same sign",
+        "simpson(f, 0, 2) = 2 (1 iterations)",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+    "fmt"
+    "math/big"
+)
+
+// DefaultPrecision is the mantissa size in bits used for inexact BigCalculator results
+const DefaultPrecision = 256
+
+// maxBigExponent bounds integer powers so a typo cannot exhaust memory
+const maxBigExponent = 100000
+
+// BigCalculator offers the Calculator method set on arbitrary-precision numbers.
+// Add, Subtract, Multiply and Divide are exact; Power is exact for integer exponents
+// and otherwise computed with the configured precision and rounding mode.
+// Operations are recorded, with their exact values, in the parent Calculator's history.
+// Views are cheap: give each goroutine its own rather than changing settings concurrently.
+type BigCalculator struct {
+    calc *Calculator
+    prec uint
+    mode big.RoundingMode
+}
+
+// Precise returns an arbitrary-precision view of the calculator.
+// A zero prec selects DefaultPrecision.
+func (c *Calculator) Precise(prec uint, mode big.RoundingMode) *BigCalculator {
+    b := &BigCalculator{calc: c, mode: mode}
+    b.SetPrecision(prec)
+    return b
+}
+
+// Precision returns the mantissa size in bits used for inexact results
+func (b *BigCalculator) Precision() uint {
+    return b.prec
+}
+
+// SetPrecision changes the mantissa size in bits; zero selects DefaultPrecision
+func (b *BigCalculator) SetPrecision(prec uint) {
+    if prec == 0 {
+        prec = DefaultPrecision
+    }
+    b.prec = prec
+}
+
+// RoundingMode returns the rounding mode used for inexact results
+func (b *BigCalculator) RoundingMode() big.RoundingMode {
+    return b.mode
+}
+
+// SetRoundingMode changes the rounding mode used for inexact results
+func (b *BigCalculator) SetRoundingMode(mode big.RoundingMode) {
+    b.mode = mode
+}
+
+// Add adds two numbers exactly
+func (b *BigCalculator) Add(x, y *big.Rat) (*big.Rat, error) {
+    return b.apply("+", x, y, func() (*big.Rat, error) {
+        return new(big.Rat).Add(x, y), nil
+    })
+}
+
+// Subtract subtracts two numbers exactly
+func (b *BigCalculator) Subtract(x, y *big.Rat) (*big.Rat, error) {
+    return b.apply("-", x, y, func() (*big.Rat, error) {
+        return new(big.Rat).Sub(x, y), nil
+    })
+}
+
+// Multiply multiplies two numbers exactly
+func (b *BigCalculator) Multiply(x, y *big.Rat) (*big.Rat, error) {
+    return b.apply("*", x, y, func() (*big.Rat, error) {
+        return new(big.Rat).Mul(x, y), nil
+    })
+}
+
+// Divide divides two numbers exactly
+func (b *BigCalculator) Divide(x, y *big.Rat) (*big.Rat, error) {
+    return b.apply("/", x, y, func() (*big.Rat, error) {
+        if y.Sign() == 0 {
+            return nil, ErrDivisionByZero
+        }
+        return new(big.Rat).Quo(x, y), nil
+    })
+}
+
+// Power raises base to exponent
+func (b *BigCalculator) Power(base, exponent *big.Rat) (*big.Rat, error) {
+    return b.apply("^", base, exponent, func() (*big.Rat, error) {
+        if exponent.IsInt() {
+            return ratPow(base, exponent.Num())
+        }
+        if base.Sign() < 0 {
+            return nil, ErrNegativeBase
+        }
+        if base.Sign() == 0 {
+            if exponent.Sign() < 0 {
+                return nil, ErrDivisionByZero
+            }
+            return new(big.Rat), nil
+        }
+        return b.floatPow(base, exponent)
+    })
+}
+
+// Float converts r to a big.Float using the configured precision and rounding mode
+func (b *BigCalculator) Float(r *big.Rat) *big.Float {
+    return new(big.Float).SetPrec(b.prec).SetMode(b.mode).SetRat(r)
+}
+
+// ParseBig parses an exact number such as "0.1", "-2.5e-3" or "1/3"
+func ParseBig(s string) (*big.Rat, error) {
+    r, ok := new(big.Rat).SetString(s)
+    if !ok {
+        return nil, fmt.Errorf("invalid number %q", s)
+    }
+    return r, nil
+}
+
+// FormatBig formats r without losing information: as a decimal when the expansion
+// terminates ("0.3", "-12.5") and as a fraction otherwise ("1/3")
+func FormatBig(r *big.Rat) string {
+    if r.IsInt() {
+        return r.Num().String()
+    }
+    // a fraction has a finite decimal expansion only if the denominator is 2^a * 5^b
+    denom := new(big.Int).Set(r.Denom())
+    two, five := big.NewInt(2), big.NewInt(5)
+    var twos, fives int
+    for new(big.Int).Mod(denom, two).Sign() == 0 {
+        denom.Quo(denom, two)
+        twos++
+    }
+    for new(big.Int).Mod(denom, five).Sign() == 0 {
+        denom.Quo(denom, five)
+        fives++
+    }
+    if denom.Cmp(big.NewInt(1)) != 0 {
+        return r.RatString()
+    }
+    return r.FloatString(max(twos, fives))
+}
+
+// apply runs op and records the exact operands and result in the history
+func (b *BigCalculator) apply(op string, x, y *big.Rat, fn func() (*big.Rat, error)) (*big.Rat, error) {
+    result, err := fn()
+    fx, _ := x.Float64()
+    fy, _ := y.Float64()
+    if err != nil {
+        name := op
+        if registered, ok := b.calc.registry.Lookup(op); ok {
+            name = registered.Name
+        }
+        err = &CalcError{Op: name, Operands: []float64{fx, fy}, Err: err}
+    }
+    entry := HistoryEntry{Operator: op, Err: err}
+    entry.Operands = []float64{fx, fy}
+    entry.OperandText = []string{FormatBig(x), FormatBig(y)}
+    if err == nil {
+        entry.Result, _ = result.Float64()
+        entry.ResultText = FormatBig(result)
+    }
+    b.calc.record(entry)
+    if err != nil {
+        return nil, err
+    }
+    return result, nil
+}
+
+// ratPow computes base^n exactly by repeated squaring; bases 0, 1 and -1 take
+// any exponent since their powers cannot grow
+func ratPow(base *big.Rat, n *big.Int) (*big.Rat, error) {
+    switch {
+    case base.Sign() == 0 && n.Sign() < 0:
+        return nil, ErrDivisionByZero
+    case base.Sign() == 0 && n.Sign() > 0:
+        return new(big.Rat), nil
+    case base.IsInt() && base.Num().CmpAbs(big.NewInt(1)) == 0:
+        if base.Sign() < 0 && n.Bit(0) == 1 {
+            return big.NewRat(-1, 1), nil
+        }
+        return big.NewRat(1, 1), nil
+    case n.CmpAbs(big.NewInt(maxBigExponent)) > 0:
+        return nil, ErrOverflow
+    }
+    k := n.Int64()
+    result := new(big.Rat).SetInt64(1)
+    square := new(big.Rat).Set(base)
+    for e := k; e != 0; e /= 2 {
+        if e%2 != 0 {
+            result.Mul(result, square)
+        }
+        square.Mul(square, square)
+    }
+    if k < 0 {
+        result.Inv(result)
+    }
+    return result, nil
+}
+
+// floatPow computes base^exponent as exp(exponent * ln(base)) for a positive base
+func (b *BigCalculator) floatPow(base, exponent *big.Rat) (*big.Rat, error) {
+    work := b.prec + 64
+    x := new(big.Float).SetPrec(work).SetRat(base)
+    y := new(big.Float).SetPrec(work).SetRat(exponent)
+    product := new(big.Float).SetPrec(work).Mul(y, bigLog(x, work))
+    if limit := big.NewFloat(1e6); new(big.Float).Abs(product).Cmp(limit) > 0 {
+        return nil, ErrOverflow
+    }
+    result := new(big.Float).SetPrec(b.prec).SetMode(b.mode).Set(bigExp(product, work))
+    r, _ := result.Rat(nil)
+    return r, nil
+}
+
+// bigLog returns ln(x) for x > 0 using x = m * 2^e and ln(m) = 2*atanh((m-1)/(m+1))
+func bigLog(x *big.Float, prec uint) *big.Float {
+    mant := new(big.Float).SetPrec(prec)
+    exp := x.MantExp(mant)
+    one := new(big.Float).SetPrec(prec).SetInt64(1)
+    z := new(big.Float).SetPrec(prec).Quo(
+        new(big.Float).SetPrec(prec).Sub(mant, one),
+        new(big.Float).SetPrec(prec).Add(mant, one),
+    )
+    result := bigAtanh2(z, prec)
+    if exp != 0 {
+        third := new(big.Float).SetPrec(prec).Quo(one, new(big.Float).SetPrec(prec).SetInt64(3))
+        ln2 := bigAtanh2(third, prec)
+        result.Add(result, ln2.Mul(ln2, new(big.Float).SetPrec(prec).SetInt64(int64(exp))))
+    }
+    return result
+}
+
+// bigAtanh2 returns 2*atanh(z) for |z| <= 1/3 from its Taylor series
+func bigAtanh2(z *big.Float, prec uint) *big.Float {
+    sum := new(big.Float).SetPrec(prec)
+    z2 := new(big.Float).SetPrec(prec).Mul(z, z)
+    power := new(big.Float).SetPrec(prec).Set(z)
+    term := new(big.Float).SetPrec(prec)
+    for k := int64(1); ; k += 2 {
+        term.Quo(power, new(big.Float).SetPrec(prec).SetInt64(k))
+        if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(prec) {
+            break
+        }
+        sum.Add(sum, term)
+        power.Mul(power, z2)
+    }
+    return sum.Mul(sum, new(big.Float).SetPrec(prec).SetInt64(2))
+}
+
+// bigExp returns e^x by halving x until the Taylor series converges quickly, then squaring back
+func bigExp(x *big.Float, prec uint) *big.Float {
+    const halvings = 16
+    r := new(big.Float).SetPrec(prec).SetMantExp(x, -halvings)
+    sum := new(big.Float).SetPrec(prec).SetInt64(1)
+    term := new(big.Float).SetPrec(prec).SetInt64(1)
+    for k := int64(1); ; k++ {
+        term.Mul(term, r)
+        term.Quo(term, new(big.Float).SetPrec(prec).SetInt64(k))
+        if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(prec) {
+            break
+        }
+        sum.Add(sum, term)
+    }
+    for i := 0; i < halvings; i++ {
+        sum.Mul(sum, sum)
+    }
+    return sum
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "math/big"
+    "strings"
+    "testing"
+)
+
+func mustParseBig(t *testing.T, s string) *big.Rat {
+    t.Helper()
+    r, err := ParseBig(s)
+    if err != nil {
+        t.Fatalf("ParseBig(%q) failed: %v", s, err)
+    }
+    return r
+}
+
+func TestBigCalculatorArithmetic(t *testing.T) {
+    calc := NewCalculator().Precise(0, big.ToNearestEven)
+    tests := []struct {
+        name     string
+        op       func(x, y *big.Rat) (*big.Rat, error)
+        x, y     string
+        expected string
+        wantErr  error
+    }{
+        {"decimal addition is exact", calc.Add, "0.1", "0.2", "0.3", nil},
+        {"no overflow", calc.Add, "1e308", "1e308", "2" + strings.Repeat("0", 308), nil},
+        {"subtraction", calc.Subtract, "0.3", "0.1", "0.2", nil},
+        {"multiplication", calc.Multiply, "1.1", "1.1", "1.21", nil},
+        {"non-terminating division", calc.Divide, "1", "3", "1/3", nil},
+        {"terminating division", calc.Divide, "1", "8", "0.125", nil},
+        {"division by zero", calc.Divide, "1", "0", "", ErrDivisionByZero},
+        {"integer power", calc.Power, "0.5", "3", "0.125", nil},
+        {"negative integer power", calc.Power, "2", "-2", "0.25", nil},
+        {"zero to a negative power", calc.Power, "0", "-1", "", ErrDivisionByZero},
+        {"negative base with non-integer exponent", calc.Power, "-2", "1.5", "", ErrNegativeBase},
+        {"huge exponent", calc.Power, "10", "1000000", "", ErrOverflow},
+        {"one to a huge power", calc.Power, "1", "1000000", "1", nil},
+        {"zero to a huge power", calc.Power, "0", "1000000", "0", nil},
+        {"minus one to a huge odd power", calc.Power, "-1", "1000001", "-1", nil},
+        {"minus one to a huge even power", calc.Power, "-1", "-1000000", "1", nil},
+        {"zero to a huge negative power", calc.Power, "0", "-1000000", "", ErrDivisionByZero},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op(mustParseBig(t, tt.x), mustParseBig(t, tt.y))
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := FormatBig(result); got != tt.expected {
+                t.Errorf("got %s, want %s", got, tt.expected)
+            }
+        })
+    }
+}
+
+
+func TestBigCalculatorFractionalPower(t *testing.T) {
+    calc := NewCalculator().Precise(128, big.ToNearestEven)
+    result, err := calc.Power(big.NewRat(2, 1), big.NewRat(1, 2))
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    sqrt2, _, _ := big.ParseFloat("1.41421356237309504880168872420969807856967187537694", 10, 256, big.ToNearestEven)
+    diff := new(big.Float).Sub(calc.Float(result), sqrt2)
+    if diff.Abs(diff).Cmp(big.NewFloat(1e-37)) > 0 {
+        t.Errorf("2^0.5 = %s, off by %s", calc.Float(result).Text('g', 40), diff.Text('g', 5))
+    }
+
+    result, err = calc.Power(big.NewRat(27, 1), big.NewRat(1, 3))
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if f, _ := result.Float64(); math.Abs(f-3) > 1e-15 {
+        t.Errorf("27^(1/3) = %v, want 3", f)
+    }
+}
+
+func TestBigCalculatorRoundingMode(t *testing.T) {
+    calc := NewCalculator().Precise(8, big.ToZero)
+    down, _ := calc.Power(big.NewRat(2, 1), big.NewRat(1, 2))
+    calc.SetRoundingMode(big.AwayFromZero)
+    up, _ := calc.Power(big.NewRat(2, 1), big.NewRat(1, 2))
+    if down.Cmp(up) >= 0 {
+        t.Errorf("expected ToZero result %s below AwayFromZero result %s", FormatBig(down), FormatBig(up))
+    }
+    if calc.Precision() != 8 || calc.RoundingMode() != big.AwayFromZero {
+        t.Errorf("unexpected configuration: %d bits, %v", calc.Precision(), calc.RoundingMode())
+    }
+}
+
+func TestBigCalculatorHistory(t *testing.T) {
+    calc := NewCalculator()
+    precise := calc.Precise(0, big.ToNearestEven)
+    precise.Add(mustParseBig(t, "0.1"), mustParseBig(t, "0.2"))
+    precise.Divide(mustParseBig(t, "2"), mustParseBig(t, "3"))
+
+    history := calc.GetHistory()
+    if len(history) != 2 {
+        t.Fatalf("expected 2 entries, got %d", len(history))
+    }
+    if history[0] != "0.1 + 0.2 = 0.3" {
+        t.Errorf("unexpected history entry: %s", history[0])
+    }
+    if history[1] != "2 / 3 = 2/3" {
+        t.Errorf("unexpected history entry: %s", history[1])
+    }
+    if entry := calc.History()[0]; entry.Result != 0.3 || entry.ResultText != "0.3" {
+        t.Errorf("unexpected entry: %+v", entry)
+    }
+}
+
+func TestFormatBigRoundTrip(t *testing.T) {
+    for _, s := range []string{"0", "-7", "0.000001", "-12.5", "1/3", "-22/7", "123456789.987654321"} {
+        r := mustParseBig(t, s)
+        formatted := FormatBig(r)
+        if back := mustParseBig(t, formatted); back.Cmp(r) != 0 {
+            t.Errorf("FormatBig(%s) = %s does not round-trip", s, formatted)
+        }
+    }
+    if _, err := ParseBig("abc"); err == nil {
+        t.Error("expected error parsing abc")
+    }
+}
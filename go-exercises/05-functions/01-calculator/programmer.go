@@ -0,0 +1,327 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// ErrInvalidIntMode is returned for unsupported word sizes or bases
+var ErrInvalidIntMode = errors.New("invalid programmer mode")
+
+// OverflowMode decides what happens when an integer result does not fit the word size
+type OverflowMode int
+
+const (
+    // Wrap keeps the low bits of the result, like integer arithmetic in Go
+    Wrap OverflowMode = iota
+    // CheckOverflow fails with ErrOverflow instead
+    CheckOverflow
+)
+
+// IntMode describes the integers a Programmer works with
+type IntMode struct {
+    Bits     int // 8, 16, 32 or 64
+    Signed   bool
+    Overflow OverflowMode
+}
+
+func (m IntMode) String() string {
+    kind := "uint"
+    if m.Signed {
+        kind = "int"
+    }
+    return fmt.Sprintf("%s%d", kind, m.Bits)
+}
+
+// Programmer is the integer mode of a Calculator. Values are passed around as
+// uint64 bit patterns of the configured word size; use Parse and Format to convert
+// them from and to text in base 2, 8, 10 or 16. Operations are recorded in the
+// parent Calculator's history with values shown in the active base. Like BigCalculator,
+// create one view per goroutine instead of calling SetBase concurrently.
+type Programmer struct {
+    calc *Calculator
+    mode IntMode
+    base int
+}
+
+// Programmer returns an integer view of the calculator working in base 10
+func (c *Calculator) Programmer(mode IntMode) (*Programmer, error) {
+    switch mode.Bits {
+    case 8, 16, 32, 64:
+    default:
+        return nil, fmt.Errorf("%w: word size %d", ErrInvalidIntMode, mode.Bits)
+    }
+    return &Programmer{calc: c, mode: mode, base: 10}, nil
+}
+
+// Mode returns the word size, signedness and overflow behaviour
+func (p *Programmer) Mode() IntMode {
+    return p.mode
+}
+
+// Base returns the base used by Format and for unprefixed input to Parse
+func (p *Programmer) Base() int {
+    return p.base
+}
+
+// SetBase selects base 2, 8, 10 or 16
+func (p *Programmer) SetBase(base int) error {
+    switch base {
+    case 2, 8, 10, 16:
+        p.base = base
+        return nil
+    }
+    return fmt.Errorf("%w: base %d", ErrInvalidIntMode, base)
+}
+
+// Parse reads an integer in the active base, or in the base given by a 0b, 0o or 0x prefix.
+// Decimal input is a value that must lie in the word's range; prefixed or non-decimal input
+// is a bit pattern that must fit in the word. A leading "-" negates either.
+func (p *Programmer) Parse(s string) (uint64, error) {
+    text := strings.TrimSpace(s)
+    negative := strings.HasPrefix(text, "-")
+    text = strings.TrimPrefix(text, "-")
+    base := p.base
+    if len(text) > 2 && text[0] == '0' {
+        switch text[1] {
+        case 'b', 'B':
+            base, text = 2, text[2:]
+        case 'o', 'O':
+            base, text = 8, text[2:]
+        case 'x', 'X':
+            base, text = 16, text[2:]
+        }
+    }
+    magnitude, ok := new(big.Int).SetString(text, base)
+    if !ok || magnitude.Sign() < 0 {
+        return 0, fmt.Errorf("%w: invalid base-%d integer %q", ErrSyntax, base, s)
+    }
+    value := magnitude
+    if base != 10 {
+        if magnitude.BitLen() > p.mode.Bits {
+            return 0, fmt.Errorf("%w: %q does not fit in %s", ErrOverflow, s, p.mode)
+        }
+        value = p.interpret(magnitude.Uint64())
+    }
+    if negative {
+        value = new(big.Int).Neg(value)
+    }
+    if !p.inRange(value) {
+        return 0, fmt.Errorf("%w: %q does not fit in %s", ErrOverflow, s, p.mode)
+    }
+    return p.pattern(value), nil
+}
+
+// Format writes v in the active base. Base 10 shows the signed or unsigned value;
+// other bases show the raw bit pattern with a 0b, 0o or 0x prefix.
+func (p *Programmer) Format(v uint64) string {
+    v &= p.mask()
+    switch p.base {
+    case 2:
+        return "0b" + new(big.Int).SetUint64(v).Text(2)
+    case 8:
+        return "0o" + new(big.Int).SetUint64(v).Text(8)
+    case 16:
+        return "0x" + new(big.Int).SetUint64(v).Text(16)
+    }
+    return p.interpret(v).String()
+}
+
+// Int64 returns the signed interpretation of v; unsigned 64-bit values above
+// math.MaxInt64 wrap around
+func (p *Programmer) Int64(v uint64) int64 {
+    return p.interpret(v & p.mask()).Int64()
+}
+
+// Add adds two integers
+func (p *Programmer) Add(a, b uint64) (uint64, error) {
+    return p.arithmetic("add", "+", a, b, func(x, y *big.Int) (*big.Int, error) {
+        return new(big.Int).Add(x, y), nil
+    })
+}
+
+// Subtract subtracts b from a
+func (p *Programmer) Subtract(a, b uint64) (uint64, error) {
+    return p.arithmetic("subtract", "-", a, b, func(x, y *big.Int) (*big.Int, error) {
+        return new(big.Int).Sub(x, y), nil
+    })
+}
+
+// Multiply multiplies two integers
+func (p *Programmer) Multiply(a, b uint64) (uint64, error) {
+    return p.arithmetic("multiply", "*", a, b, func(x, y *big.Int) (*big.Int, error) {
+        return new(big.Int).Mul(x, y), nil
+    })
+}
+
+// Divide divides a by b, truncating toward zero
+func (p *Programmer) Divide(a, b uint64) (uint64, error) {
+    return p.arithmetic("divide", "/", a, b, func(x, y *big.Int) (*big.Int, error) {
+        if y.Sign() == 0 {
+            return nil, ErrDivisionByZero
+        }
+        return new(big.Int).Quo(x, y), nil
+    })
+}
+
+// Mod returns the remainder of a / b with the sign of a
+func (p *Programmer) Mod(a, b uint64) (uint64, error) {
+    return p.arithmetic("mod", "%", a, b, func(x, y *big.Int) (*big.Int, error) {
+        if y.Sign() == 0 {
+            return nil, ErrDivisionByZero
+        }
+        return new(big.Int).Rem(x, y), nil
+    })
+}
+
+// Negate returns -a
+func (p *Programmer) Negate(a uint64) (uint64, error) {
+    return p.apply("neg", "neg", []uint64{a}, nil, func(xs []*big.Int) (*big.Int, error) {
+        return new(big.Int).Neg(xs[0]), nil
+    })
+}
+
+// And returns the bitwise AND of a and b
+func (p *Programmer) And(a, b uint64) (uint64, error) {
+    return p.bitwise("and", "&", a, b, a&b)
+}
+
+// Or returns the bitwise OR of a and b
+func (p *Programmer) Or(a, b uint64) (uint64, error) {
+    return p.bitwise("or", "|", a, b, a|b)
+}
+
+// Xor returns the bitwise exclusive OR of a and b
+func (p *Programmer) Xor(a, b uint64) (uint64, error) {
+    return p.bitwise("xor", "xor", a, b, a^b)
+}
+
+// Not flips every bit of a
+func (p *Programmer) Not(a uint64) (uint64, error) {
+    return p.record("not", "not", []uint64{a}, nil, ^a&p.mask(), nil)
+}
+
+// ShiftLeft shifts a left by n bits. In CheckOverflow mode, losing significant bits is an overflow.
+func (p *Programmer) ShiftLeft(a uint64, n uint) (uint64, error) {
+    return p.apply("shl", "<<", []uint64{a}, []uint{n}, func(xs []*big.Int) (*big.Int, error) {
+        if n > uint(p.mode.Bits) {
+            n = uint(p.mode.Bits) // every bit is gone either way
+        }
+        return new(big.Int).Lsh(xs[0], n), nil
+    })
+}
+
+// ShiftRight shifts a right by n bits: arithmetic for signed modes, logical for unsigned
+func (p *Programmer) ShiftRight(a uint64, n uint) (uint64, error) {
+    return p.apply("shr", ">>", []uint64{a}, []uint{n}, func(xs []*big.Int) (*big.Int, error) {
+        return new(big.Int).Rsh(xs[0], n), nil
+    })
+}
+
+// RotateLeft rotates the bits of a left by n positions within the word
+func (p *Programmer) RotateLeft(a uint64, n uint) (uint64, error) {
+    return p.record("rol", "rol", []uint64{a}, []uint{n}, p.rotate(a, int(n%uint(p.mode.Bits))), nil)
+}
+
+// RotateRight rotates the bits of a right by n positions within the word
+func (p *Programmer) RotateRight(a uint64, n uint) (uint64, error) {
+    bits := uint(p.mode.Bits)
+    return p.record("ror", "ror", []uint64{a}, []uint{n}, p.rotate(a, int((bits-n%bits)%bits)), nil)
+}
+
+func (p *Programmer) rotate(a uint64, left int) uint64 {
+    a &= p.mask()
+    if left == 0 {
+        return a
+    }
+    return (a<<uint(left) | a>>uint(p.mode.Bits-left)) & p.mask()
+}
+
+func (p *Programmer) arithmetic(name, symbol string, a, b uint64, fn func(x, y *big.Int) (*big.Int, error)) (uint64, error) {
+    return p.apply(name, symbol, []uint64{a, b}, nil, func(xs []*big.Int) (*big.Int, error) {
+        return fn(xs[0], xs[1])
+    })
+}
+
+func (p *Programmer) bitwise(name, symbol string, a, b, result uint64) (uint64, error) {
+    return p.record(name, symbol, []uint64{a, b}, nil, result&p.mask(), nil)
+}
+
+// apply computes an exact result from the interpreted operands and fits it to the word
+func (p *Programmer) apply(name, symbol string, operands []uint64, counts []uint, fn func(xs []*big.Int) (*big.Int, error)) (uint64, error) {
+    xs := make([]*big.Int, len(operands))
+    for i, operand := range operands {
+        xs[i] = p.interpret(operand & p.mask())
+    }
+    exact, err := fn(xs)
+    if err == nil && !p.inRange(exact) && p.mode.Overflow == CheckOverflow {
+        err = ErrOverflow
+    }
+    var result uint64
+    if err == nil {
+        result = p.pattern(exact)
+    }
+    return p.record(name, symbol, operands, counts, result, err)
+}
+
+// record adds the operation to the history with values in the active base
+func (p *Programmer) record(name, symbol string, operands []uint64, counts []uint, result uint64, err error) (uint64, error) {
+    entry := HistoryEntry{Operator: symbol}
+    for _, operand := range operands {
+        value, _ := new(big.Float).SetInt(p.interpret(operand & p.mask())).Float64()
+        entry.Operands = append(entry.Operands, value)
+        entry.OperandText = append(entry.OperandText, p.Format(operand))
+    }
+    for _, count := range counts {
+        entry.Operands = append(entry.Operands, float64(count))
+        entry.OperandText = append(entry.OperandText, fmt.Sprint(count))
+    }
+    if err != nil {
+        err = &CalcError{Op: name, Operands: entry.Operands, Err: err}
+        result = 0
+    } else {
+        entry.Result, _ = new(big.Float).SetInt(p.interpret(result)).Float64()
+        entry.ResultText = p.Format(result)
+    }
+    entry.Err = err
+    p.calc.record(entry)
+    return result, err
+}
+
+func (p *Programmer) mask() uint64 {
+    return ^uint64(0) >> (64 - p.mode.Bits)
+}
+
+// interpret turns a bit pattern into its signed or unsigned value
+func (p *Programmer) interpret(v uint64) *big.Int {
+    value := new(big.Int).SetUint64(v)
+    if p.mode.Signed && v>>(p.mode.Bits-1)&1 == 1 {
+        value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(p.mode.Bits)))
+    }
+    return value
+}
+
+// inRange reports whether value is representable in the word
+func (p *Programmer) inRange(value *big.Int) bool {
+    bits := uint(p.mode.Bits)
+    var min, max *big.Int
+    if p.mode.Signed {
+        max = new(big.Int).Lsh(big.NewInt(1), bits-1)
+        min = new(big.Int).Neg(max)
+        max.Sub(max, big.NewInt(1))
+    } else {
+        min = new(big.Int)
+        max = new(big.Int).Lsh(big.NewInt(1), bits)
+        max.Sub(max, big.NewInt(1))
+    }
+    return value.Cmp(min) >= 0 && value.Cmp(max) <= 0
+}
+
+// pattern wraps value into the word and returns its bit pattern
+func (p *Programmer) pattern(value *big.Int) uint64 {
+    modulus := new(big.Int).Lsh(big.NewInt(1), uint(p.mode.Bits))
+    wrapped := new(big.Int).Mod(value, modulus) // Euclidean, so never negative
+    return wrapped.Uint64()
+}
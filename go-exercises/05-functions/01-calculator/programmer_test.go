@@ -0,0 +1,234 @@
+package main
+
+import (
+    "errors"
+    "testing"
+)
+
+func mustProgrammer(t *testing.T, calc *Calculator, mode IntMode) *Programmer {
+    t.Helper()
+    p, err := calc.Programmer(mode)
+    if err != nil {
+        t.Fatalf("Programmer(%v) failed: %v", mode, err)
+    }
+    return p
+}
+
+func TestProgrammerArithmetic(t *testing.T) {
+    int8Wrap := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8, Signed: true})
+    int8Check := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8, Signed: true, Overflow: CheckOverflow})
+    uint8Wrap := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8})
+    uint64Check := mustProgrammer(t, NewCalculator(), IntMode{Bits: 64, Overflow: CheckOverflow})
+    int64Wrap := mustProgrammer(t, NewCalculator(), IntMode{Bits: 64, Signed: true})
+
+    tests := []struct {
+        name     string
+        p        *Programmer
+        op       func(p *Programmer, a, b uint64) (uint64, error)
+        a, b     string
+        expected string
+        wantErr  error
+    }{
+        {"int8 add", int8Wrap, (*Programmer).Add, "100", "27", "127", nil},
+        {"int8 add wraps", int8Wrap, (*Programmer).Add, "127", "1", "-128", nil},
+        {"int8 add overflows", int8Check, (*Programmer).Add, "127", "1", "", ErrOverflow},
+        {"int8 subtract negative", int8Check, (*Programmer).Subtract, "-100", "28", "-128", nil},
+        {"uint8 subtract wraps", uint8Wrap, (*Programmer).Subtract, "0", "1", "255", nil},
+        {"int8 multiply wraps", int8Wrap, (*Programmer).Multiply, "16", "16", "0", nil},
+        {"int8 divide truncates", int8Wrap, (*Programmer).Divide, "-7", "2", "-3", nil},
+        {"int8 min / -1 wraps", int8Wrap, (*Programmer).Divide, "-128", "-1", "-128", nil},
+        {"int8 min / -1 overflows", int8Check, (*Programmer).Divide, "-128", "-1", "", ErrOverflow},
+        {"division by zero", int8Wrap, (*Programmer).Divide, "1", "0", "", ErrDivisionByZero},
+        {"mod takes sign of dividend", int8Wrap, (*Programmer).Mod, "-7", "3", "-1", nil},
+        {"mod by zero", int8Wrap, (*Programmer).Mod, "7", "0", "", ErrDivisionByZero},
+        {"uint64 max", uint64Check, (*Programmer).Add, "18446744073709551614", "1", "18446744073709551615", nil},
+        {"uint64 overflows", uint64Check, (*Programmer).Multiply, "4294967296", "4294967296", "", ErrOverflow},
+        {"int64 wraps", int64Wrap, (*Programmer).Add, "9223372036854775807", "1", "-9223372036854775808", nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a, err := tt.p.Parse(tt.a)
+            if err != nil {
+                t.Fatalf("Parse(%q) failed: %v", tt.a, err)
+            }
+            b, err := tt.p.Parse(tt.b)
+            if err != nil {
+                t.Fatalf("Parse(%q) failed: %v", tt.b, err)
+            }
+            result, err := tt.op(tt.p, a, b)
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := tt.p.Format(result); got != tt.expected {
+                t.Errorf("got %s, want %s", got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestProgrammerBitwise(t *testing.T) {
+    uint8Mode := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8})
+    int8Mode := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8, Signed: true})
+    int8Check := mustProgrammer(t, NewCalculator(), IntMode{Bits: 8, Signed: true, Overflow: CheckOverflow})
+    uint16Mode := mustProgrammer(t, NewCalculator(), IntMode{Bits: 16})
+    for _, p := range []*Programmer{uint8Mode, int8Mode, int8Check, uint16Mode} {
+        if err := p.SetBase(16); err != nil {
+            t.Fatalf("SetBase(16) failed: %v", err)
+        }
+    }
+
+    tests := []struct {
+        name     string
+        p        *Programmer
+        op       func() (uint64, error)
+        expected string
+        wantErr  error
+    }{
+        {"and", uint8Mode, func() (uint64, error) { return uint8Mode.And(0xf0, 0x3c) }, "0x30", nil},
+        {"or", uint8Mode, func() (uint64, error) { return uint8Mode.Or(0xf0, 0x0f) }, "0xff", nil},
+        {"xor", uint8Mode, func() (uint64, error) { return uint8Mode.Xor(0xff, 0x0f) }, "0xf0", nil},
+        {"not", uint8Mode, func() (uint64, error) { return uint8Mode.Not(0x0f) }, "0xf0", nil},
+        {"not stays in 16 bits", uint16Mode, func() (uint64, error) { return uint16Mode.Not(0) }, "0xffff", nil},
+        {"shift left drops bits", uint8Mode, func() (uint64, error) { return uint8Mode.ShiftLeft(0x81, 1) }, "0x2", nil},
+        {"shift left past the word", uint8Mode, func() (uint64, error) { return uint8Mode.ShiftLeft(0xff, 200) }, "0x0", nil},
+        {"shift left overflows", int8Check, func() (uint64, error) { return int8Check.ShiftLeft(0x40, 1) }, "", ErrOverflow},
+        {"negative shift left fits", int8Check, func() (uint64, error) { return int8Check.ShiftLeft(0xff, 7) }, "0x80", nil},
+        {"logical shift right", uint8Mode, func() (uint64, error) { return uint8Mode.ShiftRight(0x80, 7) }, "0x1", nil},
+        {"arithmetic shift right", int8Mode, func() (uint64, error) { return int8Mode.ShiftRight(0x80, 7) }, "0xff", nil},
+        {"rotate left", uint8Mode, func() (uint64, error) { return uint8Mode.RotateLeft(0x81, 1) }, "0x3", nil},
+        {"rotate right", uint8Mode, func() (uint64, error) { return uint8Mode.RotateRight(0x81, 1) }, "0xc0", nil},
+        {"rotate by the word size", uint16Mode, func() (uint64, error) { return uint16Mode.RotateLeft(0x1234, 16) }, "0x1234", nil},
+        {"rotate right by more than the word", uint16Mode, func() (uint64, error) { return uint16Mode.RotateRight(0x1234, 20) }, "0x4123", nil},
+        {"negate", int8Mode, func() (uint64, error) { return int8Mode.Negate(0x01) }, "0xff", nil},
+        {"negate min overflows", int8Check, func() (uint64, error) { return int8Check.Negate(0x80) }, "", ErrOverflow},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op()
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := tt.p.Format(result); got != tt.expected {
+                t.Errorf("got %s, want %s", got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestProgrammerParseFormat(t *testing.T) {
+    tests := []struct {
+        name     string
+        mode     IntMode
+        base     int
+        input    string
+        expected map[int]string
+        wantErr  error
+    }{
+        {"decimal", IntMode{Bits: 8}, 10, "200", map[int]string{2: "0b11001000", 8: "0o310", 10: "200", 16: "0xc8"}, nil},
+        {"hex prefix", IntMode{Bits: 16}, 10, "0xBEEF", map[int]string{10: "48879", 16: "0xbeef"}, nil},
+        {"binary prefix", IntMode{Bits: 8}, 16, "0b101", map[int]string{10: "5"}, nil},
+        {"octal prefix", IntMode{Bits: 8}, 2, "0o17", map[int]string{10: "15"}, nil},
+        {"unprefixed input uses the base", IntMode{Bits: 8}, 16, "ff", map[int]string{10: "255"}, nil},
+        {"negative decimal", IntMode{Bits: 8, Signed: true}, 10, "-1", map[int]string{2: "0b11111111", 10: "-1", 16: "0xff"}, nil},
+        {"hex is a bit pattern", IntMode{Bits: 8, Signed: true}, 10, "0x80", map[int]string{10: "-128"}, nil},
+        {"negated hex", IntMode{Bits: 32, Signed: true}, 10, "-0x10", map[int]string{10: "-16", 16: "0xfffffff0"}, nil},
+        {"decimal out of range", IntMode{Bits: 8, Signed: true}, 10, "128", nil, ErrOverflow},
+        {"pattern too wide", IntMode{Bits: 8}, 10, "0x100", nil, ErrOverflow},
+        {"negative unsigned", IntMode{Bits: 8}, 10, "-1", nil, ErrOverflow},
+        {"bad digit", IntMode{Bits: 8}, 2, "102", nil, ErrSyntax},
+        {"empty", IntMode{Bits: 8}, 10, "", nil, ErrSyntax},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := mustProgrammer(t, NewCalculator(), tt.mode)
+            if err := p.SetBase(tt.base); err != nil {
+                t.Fatalf("SetBase(%d) failed: %v", tt.base, err)
+            }
+            v, err := p.Parse(tt.input)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            for base, want := range tt.expected {
+                p.SetBase(base)
+                if got := p.Format(v); got != want {
+                    t.Errorf("base %d: got %s, want %s", base, got, want)
+                }
+                if back, err := p.Parse(want); err != nil || back != v {
+                    t.Errorf("base %d: Parse(%q) = %#x, %v; want %#x", base, want, back, err, v)
+                }
+            }
+        })
+    }
+}
+
+func TestProgrammerInvalidMode(t *testing.T) {
+    calc := NewCalculator()
+    if _, err := calc.Programmer(IntMode{Bits: 12}); !errors.Is(err, ErrInvalidIntMode) {
+        t.Errorf("expected ErrInvalidIntMode for 12 bits, got %v", err)
+    }
+    p := mustProgrammer(t, calc, IntMode{Bits: 32})
+    if err := p.SetBase(3); !errors.Is(err, ErrInvalidIntMode) {
+        t.Errorf("expected ErrInvalidIntMode for base 3, got %v", err)
+    }
+    if p.Base() != 10 {
+        t.Errorf("base changed to %d after a failed SetBase", p.Base())
+    }
+}
+
+func TestProgrammerHistory(t *testing.T) {
+    calc := NewCalculator()
+    p := mustProgrammer(t, calc, IntMode{Bits: 8, Signed: true, Overflow: CheckOverflow})
+    p.SetBase(16)
+    p.And(0xf0, 0x3c)
+    p.RotateLeft(0x81, 1)
+    p.Add(0x7f, 0x01)
+    p.SetBase(10)
+    p.Add(0xff, 0x02)
+    p.SetBase(2)
+    p.Not(0x0f)
+
+    entries := calc.History()
+    want := []string{
+        "0xf0 & 0x3c = 0x30",
+        "rol(0x81, 1) = 0x3",
+        "0x7f + 0x1 = error: add(127, 1): result overflow",
+        "-1 + 2 = 1",
+        "not(0b1111) = 0b11110000",
+    }
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+    if entries[3].Operands[0] != -1 || entries[3].Result != 1 {
+        t.Errorf("numeric operands not recorded: %+v", entries[3])
+    }
+    if got := calc.GetHistory(); len(got) != 4 {
+        t.Errorf("GetHistory() should list the 4 successful operations, got %v", got)
+    }
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sync"
+    "unicode"
+)
+
+// ErrInvalidOperator is returned when an Operator cannot be registered
+var ErrInvalidOperator = errors.New("invalid operator")
+
+// ValidationPolicy selects the standard checks applied around an operator's function
+type ValidationPolicy int
+
+const (
+    // RejectNaN fails with ErrNaN when any operand is NaN
+    RejectNaN ValidationPolicy = 1 << iota
+    // RejectInf fails with ErrInfinite when any operand is infinite
+    RejectInf
+    // RejectOverflow fails with ErrOverflow when the result is infinite
+    RejectOverflow
+
+    // ValidateAll applies every check, as the built-in operators do
+    ValidateAll = RejectNaN | RejectInf | RejectOverflow
+)
+
+// Operator describes an operation that can be registered with a Calculator.
+// Fn only has to perform operator-specific checks such as division by zero;
+// the checks selected by Policy run around it.
+type Operator struct {
+    Name   string // e.g. "divide"; callable in expressions as divide(a, b)
+    Symbol string // e.g. "/"; recorded in history and usable infix for + - * / ^ %
+    Arity  int
+    Policy ValidationPolicy
+    Fn     func(args ...float64) (float64, error)
+}
+
+// Registry holds operators by name and by symbol. It is safe for concurrent use.
+type Registry struct {
+    mu       sync.RWMutex
+    byName   map[string]*Operator
+    bySymbol map[string]*Operator
+    order    []*Operator
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+    return &Registry{
+        byName:   make(map[string]*Operator),
+        bySymbol: make(map[string]*Operator),
+    }
+}
+
+// Register adds an operator. Names and symbols must be unique; an empty symbol defaults to the name.
+func (r *Registry) Register(op Operator) error {
+    if op.Symbol == "" {
+        op.Symbol = op.Name
+    }
+    switch {
+    case op.Name == "":
+        return fmt.Errorf("%w: missing name", ErrInvalidOperator)
+    case op.Fn == nil:
+        return fmt.Errorf("%w %q: missing function", ErrInvalidOperator, op.Name)
+    case op.Arity < 0:
+        return fmt.Errorf("%w %q: negative arity", ErrInvalidOperator, op.Name)
+    }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, ok := r.lookup(op.Name); ok {
+        return fmt.Errorf("%w %q: already registered", ErrInvalidOperator, op.Name)
+    }
+    if _, ok := r.lookup(op.Symbol); ok {
+        return fmt.Errorf("%w %q: symbol %q already registered", ErrInvalidOperator, op.Name, op.Symbol)
+    }
+    registered := op
+    r.byName[op.Name] = &registered
+    r.bySymbol[op.Symbol] = &registered
+    r.order = append(r.order, &registered)
+    return nil
+}
+
+// Lookup finds an operator by name or by symbol
+func (r *Registry) Lookup(nameOrSymbol string) (Operator, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.lookup(nameOrSymbol)
+}
+
+func (r *Registry) lookup(nameOrSymbol string) (Operator, bool) {
+    if op, ok := r.byName[nameOrSymbol]; ok {
+        return *op, true
+    }
+    if op, ok := r.bySymbol[nameOrSymbol]; ok {
+        return *op, true
+    }
+    return Operator{}, false
+}
+
+// Operators returns every registered operator in registration order
+func (r *Registry) Operators() []Operator {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    ops := make([]Operator, len(r.order))
+    for i, op := range r.order {
+        ops[i] = *op
+    }
+    return ops
+}
+
+// Call validates the operands, runs the operator and validates the result.
+// Failures are wrapped in a *CalcError naming the operator.
+func (r *Registry) Call(nameOrSymbol string, args ...float64) (float64, error) {
+    op, ok := r.Lookup(nameOrSymbol)
+    if !ok {
+        return 0, fmt.Errorf("%w %q", ErrUnknownOperator, nameOrSymbol)
+    }
+    result, err := op.call(args)
+    if err != nil {
+        return 0, &CalcError{Op: op.Name, Operands: append([]float64(nil), args...), Err: err}
+    }
+    return result, nil
+}
+
+func (op Operator) call(args []float64) (float64, error) {
+    if len(args) != op.Arity {
+        return 0, fmt.Errorf("%w: expected %d operands, got %d", ErrInvalidOperator, op.Arity, len(args))
+    }
+    for _, arg := range args {
+        if op.Policy&RejectNaN != 0 && math.IsNaN(arg) {
+            return 0, ErrNaN
+        }
+    }
+    for _, arg := range args {
+        if op.Policy&RejectInf != 0 && math.IsInf(arg, 0) {
+            return 0, ErrInfinite
+        }
+    }
+    result, err := op.Fn(args...)
+    if err != nil {
+        return 0, err
+    }
+    if math.IsNaN(result) {
+        // never let NaN reach the history, whatever the policy
+        return 0, ErrNaN
+    }
+    if op.Policy&RejectOverflow != 0 && math.IsInf(result, 0) {
+        return 0, ErrOverflow
+    }
+    return result, nil
+}
+
+// isInfix reports whether a symbol is punctuation written between its operands, like "+"
+func isInfix(symbol string) bool {
+    for _, r := range symbol {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) {
+            return false
+        }
+    }
+    return symbol != ""
+}
+
+// Register adds a custom operator to this calculator
+func (c *Calculator) Register(op Operator) error {
+    return c.registry.Register(op)
+}
+
+// Operators lists the operators this calculator knows, built-ins first
+func (c *Calculator) Operators() []Operator {
+    return c.registry.Operators()
+}
+
+// Apply runs the operator with the given name or symbol and records it in the history
+func (c *Calculator) Apply(nameOrSymbol string, args ...float64) (float64, error) {
+    result, err := c.registry.Call(nameOrSymbol, args...)
+    symbol := nameOrSymbol
+    if op, ok := c.registry.Lookup(nameOrSymbol); ok {
+        symbol = op.Symbol
+    }
+    c.record(HistoryEntry{Operator: symbol, Operands: append([]float64(nil), args...), Result: result, Err: err})
+    if err != nil {
+        return 0, err
+    }
+    return result, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+// percent is a custom operator used to exercise the registry
+var percent = Operator{
+    Name:   "percent",
+    Arity:  2,
+    Policy: ValidateAll,
+    Fn: func(args ...float64) (float64, error) {
+        if args[1] == 0 {
+            return 0, ErrDivisionByZero
+        }
+        return args[0] / args[1] * 100, nil
+    },
+}
+
+func TestBuiltinOperators(t *testing.T) {
+    expected := []struct{ name, symbol string }{
+        {"add", "+"}, {"subtract", "-"}, {"multiply", "*"}, {"divide", "/"}, {"power", "^"},
+    }
+    ops := NewCalculator().Operators()
+    if len(ops) < len(expected) {
+        t.Fatalf("expected at least %d built-in operators, got %d", len(expected), len(ops))
+    }
+    for i, want := range expected {
+        if ops[i].Name != want.name || ops[i].Symbol != want.symbol || ops[i].Arity != 2 || ops[i].Policy != ValidateAll {
+            t.Errorf("operator %d = %+v, want %s %s", i, ops[i], want.name, want.symbol)
+        }
+    }
+}
+
+// TestRegisteredOperatorsValidate checks every registered operator, including custom ones,
+// against the validation policy it declares
+func TestRegisteredOperatorsValidate(t *testing.T) {
+    calc := NewCalculator()
+    if err := calc.Register(percent); err != nil {
+        t.Fatalf("Register failed: %v", err)
+    }
+
+    for _, op := range calc.Operators() {
+        t.Run(op.Name, func(t *testing.T) {
+            args := make([]float64, op.Arity)
+            for i := range args {
+                args[i] = 1
+            }
+            if _, err := calc.Apply(op.Name, args...); err != nil {
+                t.Errorf("%s with valid operands failed: %v", op.Name, err)
+            }
+
+            checks := []struct {
+                policy ValidationPolicy
+                value  float64
+                want   error
+            }{
+                {RejectNaN, math.NaN(), ErrNaN},
+                {RejectInf, math.Inf(1), ErrInfinite},
+            }
+            for _, check := range checks {
+                if op.Policy&check.policy == 0 {
+                    continue
+                }
+                bad := append([]float64(nil), args...)
+                bad[0] = check.value
+                _, err := calc.Apply(op.Name, bad...)
+                if !errors.Is(err, check.want) {
+                    t.Errorf("%s(%v) = %v, want %v", op.Name, bad, err, check.want)
+                }
+                var calcErr *CalcError
+                if !errors.As(err, &calcErr) || calcErr.Op != op.Name {
+                    t.Errorf("expected *CalcError for %s, got %v", op.Name, err)
+                }
+                if last := calc.History().Last(1)[0]; last.Operator != op.Symbol || last.Err == nil {
+                    t.Errorf("failure not recorded in history: %+v", last)
+                }
+            }
+        })
+    }
+}
+
+func TestCustomOperator(t *testing.T) {
+    calc := NewCalculator()
+    if err := calc.Register(percent); err != nil {
+        t.Fatalf("Register failed: %v", err)
+    }
+
+    if result, err := calc.Apply("percent", 1, 4); err != nil || result != 25 {
+        t.Errorf("Apply(percent, 1, 4) = %v, %v", result, err)
+    }
+    if _, err := calc.Apply("percent", 7, 0); !errors.Is(err, ErrDivisionByZero) {
+        t.Errorf("expected ErrDivisionByZero, got %v", err)
+    }
+    if result, err := calc.Evaluate("2 * percent(3, 4) + 1"); err != nil || result != 151 {
+        t.Errorf("Evaluate = %v, %v, want 151", result, err)
+    }
+
+    history := calc.GetHistory()
+    if history[0] != "percent(1.000000, 4.000000) = 25.000000" {
+        t.Errorf("unexpected history entry: %s", history[0])
+    }
+
+    // replay keeps the calculator's custom operators
+    if mismatches := NewSession(calc).Replay(calc.History()); len(mismatches) != 0 {
+        t.Errorf("unexpected replay mismatches: %v", mismatches)
+    }
+
+    // other calculators are unaffected
+    if _, err := NewCalculator().Evaluate("percent(7, 3)"); !errors.Is(err, ErrUnknownOperator) {
+        t.Errorf("expected ErrUnknownOperator, got %v", err)
+    }
+}
+
+func TestOperatorNaNResult(t *testing.T) {
+    calc := NewCalculator()
+    // no validation policy, and a function that can produce NaN
+    calc.Register(Operator{Name: "nan", Symbol: "nan", Arity: 1, Fn: func(args ...float64) (float64, error) {
+        return math.NaN(), nil
+    }})
+    if _, err := calc.Apply("nan", 1); !errors.Is(err, ErrNaN) {
+        t.Errorf("expected ErrNaN, got %v", err)
+    }
+    if last := calc.History().Last(1)[0]; last.Err == nil || math.IsNaN(last.Result) {
+        t.Errorf("NaN recorded in history: %+v", last)
+    }
+}
+
+func TestExpressionCalls(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        expr     string
+        expected float64
+        wantErr  error
+    }{
+        {"add(1, 2)", 3, nil},
+        {"power(2, add(1, 2)) - 1", 7, nil},
+        {"divide(1, 0)", 0, ErrDivisionByZero},
+        {"add(1)", 0, ErrInvalidOperator},
+        {"nope(1)", 0, ErrUnknownOperator},
+        {"add(1, 2", 0, ErrSyntax},
+        {"add(1 2)", 0, ErrSyntax},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            result, err := calc.Evaluate(tt.expr)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil || result != tt.expected {
+                t.Errorf("Evaluate(%q) = %v, %v, want %v", tt.expr, result, err, tt.expected)
+            }
+        })
+    }
+}
+
+func TestRegisterErrors(t *testing.T) {
+    fn := func(args ...float64) (float64, error) { return 0, nil }
+    tests := []struct {
+        name string
+        op   Operator
+    }{
+        {"missing name", Operator{Arity: 1, Fn: fn}},
+        {"missing function", Operator{Name: "f", Arity: 1}},
+        {"negative arity", Operator{Name: "f", Arity: -1, Fn: fn}},
+        {"duplicate name", Operator{Name: "add", Symbol: "plus", Arity: 2, Fn: fn}},
+        {"duplicate symbol", Operator{Name: "plus", Symbol: "+", Arity: 2, Fn: fn}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := NewCalculator().Register(tt.op); !errors.Is(err, ErrInvalidOperator) {
+                t.Errorf("expected ErrInvalidOperator, got %v", err)
+            }
+        })
+    }
+}
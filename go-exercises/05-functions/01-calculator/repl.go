@@ -0,0 +1,229 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// errQuit is returned by Execute when the user asks to leave the REPL
+var errQuit = errors.New("quit")
+
+// assignment matches "name = expression"
+var assignment = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*=(.*)$`)
+
+const replHelp = `expressions:  2 * (3 + 4) ^ 2, using variables and ans for the last result
+assignment:   x = 3 * 4
+commands:     history, clear, undo, redo, vars, help, quit
+functions:    sin cos tan asin acos atan log ln log2 exp sqrt root fact gamma ncr npr mod floor ceil round
+settings:     :precision N prints N significant digits (0 for shortest); :digits N is the same
+              :angle deg|rad selects the unit of trigonometric functions`
+
+// REPL is a read-eval-print loop around a calculator Session
+type REPL struct {
+    session   *Session
+    vars      map[string]float64
+    precision int
+    inputs    []string // lines entered at the terminal, recalled with the arrow keys
+}
+
+// NewREPL creates a REPL with a fresh calculator
+func NewREPL() *REPL {
+    return &REPL{
+        session:   NewSession(nil),
+        vars:      make(map[string]float64),
+        precision: -1,
+    }
+}
+
+// Run reads lines from in and writes results to out until in is exhausted or the user quits.
+// In interactive mode errors are printed and the loop continues; otherwise Run stops
+// and returns the first error, annotated with its line number.
+func (r *REPL) Run(in io.Reader, out io.Writer, interactive bool) error {
+    readLine, done := r.lineReader(in, out, interactive)
+    defer done()
+    for lineNo := 1; ; lineNo++ {
+        text, err := readLine()
+        if err == io.EOF {
+            if interactive {
+                fmt.Fprintln(out)
+            }
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        line := strings.TrimSpace(text)
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        output, err := r.Execute(line)
+        if errors.Is(err, errQuit) {
+            return nil
+        }
+        if err != nil {
+            if !interactive {
+                return fmt.Errorf("line %d: %w", lineNo, err)
+            }
+            fmt.Fprintf(out, "error: %v\n", err)
+            continue
+        }
+        if output != "" {
+            fmt.Fprintln(out, output)
+        }
+    }
+}
+
+// lineReader returns a function that reads the next line and one that cleans up afterwards.
+// Interactive sessions on a terminal get a line editor with history; any other input,
+// or a terminal that cannot be switched to raw mode, is read a line at a time.
+func (r *REPL) lineReader(in io.Reader, out io.Writer, interactive bool) (func() (string, error), func()) {
+    if f, ok := in.(*os.File); ok && interactive {
+        if restore, err := makeRaw(f.Fd()); err == nil {
+            editor := newLineEditor(f, out, r.inputs)
+            return func() (string, error) { return editor.readLine("> ") }, func() {
+                restore()
+                r.inputs = editor.history
+            }
+        }
+    }
+    scanner := bufio.NewScanner(in)
+    return func() (string, error) {
+        if interactive {
+            fmt.Fprint(out, "> ")
+        }
+        if !scanner.Scan() {
+            if err := scanner.Err(); err != nil {
+                return "", err
+            }
+            return "", io.EOF
+        }
+        return scanner.Text(), nil
+    }, func() {}
+}
+
+// Execute runs a single command, assignment or expression and returns the text to print
+func (r *REPL) Execute(line string) (string, error) {
+    line = strings.TrimSpace(line)
+    fields := strings.Fields(line)
+    if len(fields) == 0 {
+        return "", nil
+    }
+
+    switch fields[0] {
+    case "quit", "exit":
+        return "", errQuit
+    case "help":
+        return replHelp, nil
+    case "history":
+        return strings.Join(r.session.Calculator().History().Strings(), "\n"), nil
+    case "clear":
+        r.session.Calculator().ClearHistory()
+        r.session = NewSession(r.session.Calculator())
+        return "", nil
+    case "undo":
+        if err := r.session.Undo(); err != nil {
+            return "", err
+        }
+        return r.format(r.session.Value()), nil
+    case "redo":
+        if err := r.session.Redo(); err != nil {
+            return "", err
+        }
+        return r.format(r.session.Value()), nil
+    case "vars":
+        return r.listVars(), nil
+    case ":precision", ":digits":
+        return r.setPrecision(fields[1:])
+    case ":angle":
+        return r.setAngle(fields[1:])
+    }
+
+    if m := assignment.FindStringSubmatch(line); m != nil {
+        name := m[1]
+        if name == "ans" {
+            return "", errors.New("ans is read-only")
+        }
+        result, err := r.evaluate(m[2])
+        if err != nil {
+            return "", err
+        }
+        r.vars[name] = result
+        return fmt.Sprintf("%s = %s", name, r.format(result)), nil
+    }
+
+    result, err := r.evaluate(line)
+    if err != nil {
+        return "", err
+    }
+    return r.format(result), nil
+}
+
+// evaluate runs expr through the session with the user's variables and ans in scope
+func (r *REPL) evaluate(expr string) (float64, error) {
+    scope := make(map[string]float64, len(r.vars)+1)
+    for name, value := range r.vars {
+        scope[name] = value
+    }
+    scope["ans"] = r.session.Value()
+    return r.session.EvaluateWith(expr, scope)
+}
+
+// setPrecision changes how many significant digits results are printed with;
+// calculations always use full float64 precision
+func (r *REPL) setPrecision(args []string) (string, error) {
+    if len(args) == 0 {
+        if r.precision < 0 {
+            return "precision: shortest", nil
+        }
+        return fmt.Sprintf("precision: %d", r.precision), nil
+    }
+    digits, err := strconv.Atoi(args[0])
+    if err != nil || digits < 0 {
+        return "", fmt.Errorf("invalid precision %q", args[0])
+    }
+    if digits == 0 {
+        digits = -1
+    }
+    r.precision = digits
+    return "", nil
+}
+
+func (r *REPL) setAngle(args []string) (string, error) {
+    calc := r.session.Calculator()
+    if len(args) == 0 {
+        return "angle: " + calc.AngleMode().String(), nil
+    }
+    switch args[0] {
+    case "deg", "degrees":
+        calc.SetAngleMode(Degrees)
+    case "rad", "radians":
+        calc.SetAngleMode(Radians)
+    default:
+        return "", fmt.Errorf("invalid angle mode %q", args[0])
+    }
+    return "", nil
+}
+
+func (r *REPL) listVars() string {
+    names := make([]string, 0, len(r.vars))
+    for name := range r.vars {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    lines := make([]string, len(names))
+    for i, name := range names {
+        lines[i] = fmt.Sprintf("%s = %s", name, r.format(r.vars[name]))
+    }
+    return strings.Join(lines, "\n")
+}
+
+func (r *REPL) format(v float64) string {
+    return strconv.FormatFloat(v, 'g', r.precision, 64)
+}
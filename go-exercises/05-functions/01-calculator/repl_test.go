@@ -0,0 +1,118 @@
+package main
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+)
+
+func TestREPLExecute(t *testing.T) {
+    repl := NewREPL()
+    steps := []struct {
+        line     string
+        expected string
+        wantErr  bool
+    }{
+        {"2 + 3 * 4", "14", false},
+        {"ans / 2", "7", false},
+        {"x = 3 * 4", "x = 12", false},
+        {"x + ans", "24", false},
+        {"y", "", true},
+        {"ans = 1", "", true},
+        {"1 / 0", "", true},
+        {"undo", "12", false},
+        {"undo", "7", false},
+        {"redo", "12", false},
+        {":precision 3", "", false},
+        {"10 / 3", "3.33", false},
+        {":precision", "precision: 3", false},
+        {":precision 0", "", false},
+        {":precision -1", "", true},
+        {":digits 2", "", false},
+        {"10 / 3", "3.3", false},
+        {":digits", "precision: 2", false},
+        {":digits 0", "", false},
+        {"vars", "x = 12", false},
+        {":angle deg", "", false},
+        {"sin(90) + sqrt(16)", "5", false},
+        {":angle", "angle: degrees", false},
+        {":angle grad", "", true},
+        {"2 +", "", true},
+    }
+
+    for _, step := range steps {
+        got, err := repl.Execute(step.line)
+        if step.wantErr {
+            if err == nil {
+                t.Errorf("%q: expected error but got %q", step.line, got)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("%q: unexpected error: %v", step.line, err)
+            continue
+        }
+        if got != step.expected {
+            t.Errorf("%q: got %q, want %q", step.line, got, step.expected)
+        }
+    }
+}
+
+func TestREPLHistoryAndClear(t *testing.T) {
+    repl := NewREPL()
+    repl.Execute("1 + 1")
+    repl.Execute("x = 2 * 3")
+
+    history, _ := repl.Execute("history")
+    if history != "1 + 1 = 2.000000\n2 * 3 = 6.000000" {
+        t.Errorf("unexpected history:\n%s", history)
+    }
+
+    repl.Execute("clear")
+    if history, _ := repl.Execute("history"); history != "" {
+        t.Errorf("expected empty history after clear, got %q", history)
+    }
+    if _, err := repl.Execute("undo"); err == nil {
+        t.Error("expected nothing to undo after clear")
+    }
+    if got, _ := repl.Execute("ans"); got != "0" {
+        t.Errorf("expected ans to reset after clear, got %q", got)
+    }
+}
+
+func TestREPLRunInteractive(t *testing.T) {
+    in := strings.NewReader("1 + 2\n\nbogus +\nans * 2\nquit\n99\n")
+    var out bytes.Buffer
+    if err := NewREPL().Run(in, &out, true); err != nil {
+        t.Fatalf("Run returned error: %v", err)
+    }
+    got := out.String()
+    for _, want := range []string{"> 3\n", "error: ", "> 6\n"} {
+        if !strings.Contains(got, want) {
+            t.Errorf("output %q does not contain %q", got, want)
+        }
+    }
+    if strings.Contains(got, "99") {
+        t.Errorf("lines after quit should not run: %q", got)
+    }
+}
+
+func TestREPLRunScript(t *testing.T) {
+    script := "# comment\nx = 4\nx ^ 2\n"
+    var out bytes.Buffer
+    if err := NewREPL().Run(strings.NewReader(script), &out, false); err != nil {
+        t.Fatalf("Run returned error: %v", err)
+    }
+    if out.String() != "x = 4\n16\n" {
+        t.Errorf("unexpected output %q", out.String())
+    }
+
+    out.Reset()
+    err := NewREPL().Run(strings.NewReader("1 + 1\n\n1 / 0\n2 + 2\n"), &out, false)
+    if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+        t.Errorf("expected error on line 3, got %v", err)
+    }
+    if out.String() != "2\n" {
+        t.Errorf("script should stop at the first error, got %q", out.String())
+    }
+}
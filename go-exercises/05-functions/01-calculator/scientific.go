@@ -0,0 +1,365 @@
+package main
+
+import (
+    "errors"
+    "math"
+)
+
+// ErrDomain is returned when an argument lies outside a function's domain, e.g. sqrt(-1)
+var ErrDomain = errors.New("invalid operation: argument outside function domain")
+
+// AngleMode selects the unit used by trigonometric functions
+type AngleMode int
+
+const (
+    Radians AngleMode = iota
+    Degrees
+)
+
+func (m AngleMode) String() string {
+    if m == Degrees {
+        return "degrees"
+    }
+    return "radians"
+}
+
+// AngleMode returns the unit used by trigonometric functions
+func (c *Calculator) AngleMode() AngleMode {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.angleMode
+}
+
+// SetAngleMode changes the unit used by trigonometric functions
+func (c *Calculator) SetAngleMode(mode AngleMode) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.angleMode = mode
+}
+
+// Sin returns the sine of x
+func (c *Calculator) Sin(x float64) (float64, error) {
+    return c.Apply("sin", x)
+}
+
+// Cos returns the cosine of x
+func (c *Calculator) Cos(x float64) (float64, error) {
+    return c.Apply("cos", x)
+}
+
+// Tan returns the tangent of x
+func (c *Calculator) Tan(x float64) (float64, error) {
+    return c.Apply("tan", x)
+}
+
+// Asin returns the arcsine of x, which must lie in [-1, 1]
+func (c *Calculator) Asin(x float64) (float64, error) {
+    return c.Apply("asin", x)
+}
+
+// Acos returns the arccosine of x, which must lie in [-1, 1]
+func (c *Calculator) Acos(x float64) (float64, error) {
+    return c.Apply("acos", x)
+}
+
+// Atan returns the arctangent of x
+func (c *Calculator) Atan(x float64) (float64, error) {
+    return c.Apply("atan", x)
+}
+
+// Log returns the base-10 logarithm of x
+func (c *Calculator) Log(x float64) (float64, error) {
+    return c.Apply("log", x)
+}
+
+// Ln returns the natural logarithm of x
+func (c *Calculator) Ln(x float64) (float64, error) {
+    return c.Apply("ln", x)
+}
+
+// Log2 returns the base-2 logarithm of x
+func (c *Calculator) Log2(x float64) (float64, error) {
+    return c.Apply("log2", x)
+}
+
+// Exp returns e raised to the power of x
+func (c *Calculator) Exp(x float64) (float64, error) {
+    return c.Apply("exp", x)
+}
+
+// Sqrt returns the square root of x
+func (c *Calculator) Sqrt(x float64) (float64, error) {
+    return c.Apply("sqrt", x)
+}
+
+// Root returns the nth root of x; negative x is only allowed for odd integer n
+func (c *Calculator) Root(x, n float64) (float64, error) {
+    return c.Apply("root", x, n)
+}
+
+// Factorial returns n! for a non-negative integer n
+func (c *Calculator) Factorial(n float64) (float64, error) {
+    return c.Apply("fact", n)
+}
+
+// Gamma returns the gamma function of x
+func (c *Calculator) Gamma(x float64) (float64, error) {
+    return c.Apply("gamma", x)
+}
+
+// Combinations returns nCr, the number of ways to choose r items from n
+func (c *Calculator) Combinations(n, r float64) (float64, error) {
+    return c.Apply("ncr", n, r)
+}
+
+// Permutations returns nPr, the number of ordered arrangements of r items from n
+func (c *Calculator) Permutations(n, r float64) (float64, error) {
+    return c.Apply("npr", n, r)
+}
+
+// Mod returns the remainder of a / b with the sign of a
+func (c *Calculator) Mod(a, b float64) (float64, error) {
+    return c.Apply("mod", a, b)
+}
+
+// Floor returns the greatest integer less than or equal to x
+func (c *Calculator) Floor(x float64) (float64, error) {
+    return c.Apply("floor", x)
+}
+
+// Ceil returns the least integer greater than or equal to x
+func (c *Calculator) Ceil(x float64) (float64, error) {
+    return c.Apply("ceil", x)
+}
+
+// Round rounds x half away from zero to the given number of decimal digits;
+// negative digits round to tens, hundreds and so on
+func (c *Calculator) Round(x, digits float64) (float64, error) {
+    return c.Apply("round", x, digits)
+}
+
+// registerScientific adds the scientific functions to the calculator's registry.
+// Trigonometric functions read the calculator's angle mode once when they run.
+func (c *Calculator) registerScientific() {
+    unary := func(fn func(x float64) (float64, error)) func(args ...float64) (float64, error) {
+        return func(args ...float64) (float64, error) {
+            return fn(args[0])
+        }
+    }
+    binary := func(fn func(a, b float64) (float64, error)) func(args ...float64) (float64, error) {
+        return func(args ...float64) (float64, error) {
+            return fn(args[0], args[1])
+        }
+    }
+    ops := []Operator{
+        {Name: "sin", Arity: 1, Fn: unary(c.sin)},
+        {Name: "cos", Arity: 1, Fn: unary(c.cos)},
+        {Name: "tan", Arity: 1, Fn: unary(c.tan)},
+        {Name: "asin", Arity: 1, Fn: unary(c.inverseTrig(math.Asin, true))},
+        {Name: "acos", Arity: 1, Fn: unary(c.inverseTrig(math.Acos, true))},
+        {Name: "atan", Arity: 1, Fn: unary(c.inverseTrig(math.Atan, false))},
+        {Name: "log", Arity: 1, Fn: unary(logarithm(math.Log10))},
+        {Name: "ln", Arity: 1, Fn: unary(logarithm(math.Log))},
+        {Name: "log2", Arity: 1, Fn: unary(logarithm(math.Log2))},
+        {Name: "exp", Arity: 1, Fn: unary(exponential)},
+        {Name: "sqrt", Arity: 1, Fn: unary(squareRoot)},
+        {Name: "root", Arity: 2, Fn: binary(nthRoot)},
+        {Name: "fact", Arity: 1, Fn: unary(factorial)},
+        {Name: "gamma", Arity: 1, Fn: unary(gamma)},
+        {Name: "ncr", Arity: 2, Fn: binary(combinations)},
+        {Name: "npr", Arity: 2, Fn: binary(permutations)},
+        {Name: "mod", Symbol: "%", Arity: 2, Fn: binary(modulo)},
+        {Name: "floor", Arity: 1, Fn: unary(floor)},
+        {Name: "ceil", Arity: 1, Fn: unary(ceil)},
+        {Name: "round", Arity: 2, Fn: binary(round)},
+    }
+    for _, op := range ops {
+        op.Policy = ValidateAll
+        if err := c.registry.Register(op); err != nil {
+            panic(err)
+        }
+    }
+}
+
+// toRadians converts x from the given angle mode
+func toRadians(mode AngleMode, x float64) float64 {
+    if mode == Degrees {
+        return x * math.Pi / 180
+    }
+    return x
+}
+
+// fromRadians converts x to the given angle mode
+func fromRadians(mode AngleMode, x float64) float64 {
+    if mode == Degrees {
+        return x * 180 / math.Pi
+    }
+    return x
+}
+
+// quarterTurns reports how many right angles x is when it is an exact multiple
+// of 90 degrees, so that sin(180) is exactly 0 in degree mode
+func quarterTurns(mode AngleMode, x float64) (int, bool) {
+    if mode != Degrees || math.Mod(x, 90) != 0 {
+        return 0, false
+    }
+    turns := int(math.Mod(x/90, 4))
+    if turns < 0 {
+        turns += 4
+    }
+    return turns, true
+}
+
+func (c *Calculator) sin(x float64) (float64, error) {
+    mode := c.AngleMode()
+    if turns, ok := quarterTurns(mode, x); ok {
+        return [4]float64{0, 1, 0, -1}[turns], nil
+    }
+    return math.Sin(toRadians(mode, x)), nil
+}
+
+func (c *Calculator) cos(x float64) (float64, error) {
+    mode := c.AngleMode()
+    if turns, ok := quarterTurns(mode, x); ok {
+        return [4]float64{1, 0, -1, 0}[turns], nil
+    }
+    return math.Cos(toRadians(mode, x)), nil
+}
+
+func (c *Calculator) tan(x float64) (float64, error) {
+    mode := c.AngleMode()
+    if turns, ok := quarterTurns(mode, x); ok {
+        if turns%2 == 1 {
+            return 0, ErrDomain
+        }
+        return 0, nil
+    }
+    return math.Tan(toRadians(mode, x)), nil
+}
+
+// inverseTrig wraps asin, acos and atan, converting the result to the angle mode
+func (c *Calculator) inverseTrig(fn func(float64) float64, unitInterval bool) func(x float64) (float64, error) {
+    return func(x float64) (float64, error) {
+        if unitInterval && (x < -1 || x > 1) {
+            return 0, ErrDomain
+        }
+        return fromRadians(c.AngleMode(), fn(x)), nil
+    }
+}
+
+func logarithm(fn func(float64) float64) func(x float64) (float64, error) {
+    return func(x float64) (float64, error) {
+        if x <= 0 {
+            return 0, ErrDomain
+        }
+        return fn(x), nil
+    }
+}
+
+func exponential(x float64) (float64, error) {
+    return math.Exp(x), nil
+}
+
+func squareRoot(x float64) (float64, error) {
+    if x < 0 {
+        return 0, ErrDomain
+    }
+    return math.Sqrt(x), nil
+}
+
+func nthRoot(x, n float64) (float64, error) {
+    if n == 0 {
+        return 0, ErrDomain
+    }
+    if x >= 0 {
+        return math.Pow(x, 1/n), nil
+    }
+    // odd integer roots of negative numbers are real
+    if n != math.Trunc(n) || math.Mod(n, 2) == 0 {
+        return 0, ErrDomain
+    }
+    return -math.Pow(-x, 1/n), nil
+}
+
+// isNonNegativeInteger guards the combinatorial functions
+func isNonNegativeInteger(x float64) bool {
+    return x >= 0 && x == math.Trunc(x)
+}
+
+func factorial(n float64) (float64, error) {
+    if !isNonNegativeInteger(n) {
+        return 0, ErrDomain
+    }
+    if n > 170 {
+        return math.Inf(1), nil // reported as overflow
+    }
+    result := 1.0
+    for i := 2.0; i <= n; i++ {
+        result *= i
+    }
+    return result, nil
+}
+
+func gamma(x float64) (float64, error) {
+    if x <= 0 && x == math.Trunc(x) {
+        return 0, ErrDomain
+    }
+    return math.Gamma(x), nil
+}
+
+func combinations(n, r float64) (float64, error) {
+    if !isNonNegativeInteger(n) || !isNonNegativeInteger(r) || r > n {
+        return 0, ErrDomain
+    }
+    r = math.Min(r, n-r)
+    result := 1.0
+    for i := 1.0; i <= r && !math.IsInf(result, 0); i++ {
+        result = result * (n - r + i) / i
+    }
+    return math.Round(result), nil
+}
+
+func permutations(n, r float64) (float64, error) {
+    if !isNonNegativeInteger(n) || !isNonNegativeInteger(r) || r > n {
+        return 0, ErrDomain
+    }
+    if r == 0 {
+        return 1, nil
+    }
+    // count with an int: above 2^53, n-r+1 as a float64 counter would stop changing
+    result := 1.0
+    for k := 0; float64(k) < r && !math.IsInf(result, 0); k++ {
+        result *= n - float64(k)
+    }
+    return result, nil
+}
+
+func modulo(a, b float64) (float64, error) {
+    if b == 0 {
+        return 0, ErrDivisionByZero
+    }
+    return math.Mod(a, b), nil
+}
+
+func floor(x float64) (float64, error) {
+    return math.Floor(x), nil
+}
+
+func ceil(x float64) (float64, error) {
+    return math.Ceil(x), nil
+}
+
+func round(x, digits float64) (float64, error) {
+    if digits != math.Trunc(digits) {
+        return 0, ErrDomain
+    }
+    scale := math.Pow(10, digits)
+    switch {
+    case scale == 0:
+        return 0, nil
+    case math.IsInf(scale, 0), math.IsInf(x*scale, 0):
+        // x already has fewer significant digits than requested
+        return x, nil
+    }
+    return math.Round(x*scale) / scale, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestScientificFunctions(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        op       func() (float64, error)
+        expected float64
+        wantErr  error
+    }{
+        {"sin", func() (float64, error) { return calc.Sin(math.Pi / 2) }, 1, nil},
+        {"cos", func() (float64, error) { return calc.Cos(0) }, 1, nil},
+        {"tan", func() (float64, error) { return calc.Tan(math.Pi / 4) }, 1, nil},
+        {"asin", func() (float64, error) { return calc.Asin(1) }, math.Pi / 2, nil},
+        {"asin out of domain", func() (float64, error) { return calc.Asin(1.5) }, 0, ErrDomain},
+        {"acos", func() (float64, error) { return calc.Acos(-1) }, math.Pi, nil},
+        {"acos out of domain", func() (float64, error) { return calc.Acos(-2) }, 0, ErrDomain},
+        {"atan", func() (float64, error) { return calc.Atan(1) }, math.Pi / 4, nil},
+        {"log", func() (float64, error) { return calc.Log(1000) }, 3, nil},
+        {"log of zero", func() (float64, error) { return calc.Log(0) }, 0, ErrDomain},
+        {"ln", func() (float64, error) { return calc.Ln(math.E) }, 1, nil},
+        {"ln of negative", func() (float64, error) { return calc.Ln(-1) }, 0, ErrDomain},
+        {"log2", func() (float64, error) { return calc.Log2(1024) }, 10, nil},
+        {"exp", func() (float64, error) { return calc.Exp(1) }, math.E, nil},
+        {"exp overflow", func() (float64, error) { return calc.Exp(1000) }, 0, ErrOverflow},
+        {"sqrt", func() (float64, error) { return calc.Sqrt(16) }, 4, nil},
+        {"sqrt of negative", func() (float64, error) { return calc.Sqrt(-4) }, 0, ErrDomain},
+        {"root", func() (float64, error) { return calc.Root(27, 3) }, 3, nil},
+        {"odd root of negative", func() (float64, error) { return calc.Root(-32, 5) }, -2, nil},
+        {"even root of negative", func() (float64, error) { return calc.Root(-16, 4) }, 0, ErrDomain},
+        {"zeroth root", func() (float64, error) { return calc.Root(8, 0) }, 0, ErrDomain},
+        {"factorial", func() (float64, error) { return calc.Factorial(5) }, 120, nil},
+        {"factorial of zero", func() (float64, error) { return calc.Factorial(0) }, 1, nil},
+        {"factorial of fraction", func() (float64, error) { return calc.Factorial(2.5) }, 0, ErrDomain},
+        {"factorial of negative", func() (float64, error) { return calc.Factorial(-1) }, 0, ErrDomain},
+        {"factorial overflow", func() (float64, error) { return calc.Factorial(171) }, 0, ErrOverflow},
+        {"gamma", func() (float64, error) { return calc.Gamma(5) }, 24, nil},
+        {"gamma of half", func() (float64, error) { return calc.Gamma(0.5) }, math.Sqrt(math.Pi), nil},
+        {"gamma pole", func() (float64, error) { return calc.Gamma(-2) }, 0, ErrDomain},
+        {"combinations", func() (float64, error) { return calc.Combinations(5, 2) }, 10, nil},
+        {"large combinations", func() (float64, error) { return calc.Combinations(52, 5) }, 2598960, nil},
+        {"combinations r > n", func() (float64, error) { return calc.Combinations(2, 5) }, 0, ErrDomain},
+        {"combinations overflow", func() (float64, error) { return calc.Combinations(1e6, 5e5) }, 0, ErrOverflow},
+        {"permutations", func() (float64, error) { return calc.Permutations(5, 2) }, 20, nil},
+        {"permutations of none", func() (float64, error) { return calc.Permutations(5, 0) }, 1, nil},
+        {"permutations of fraction", func() (float64, error) { return calc.Permutations(5.5, 2) }, 0, ErrDomain},
+        {"permutations of a huge n", func() (float64, error) { return calc.Permutations(1e18, 1) }, 1e18, nil},
+        {"permutations of a huge n twice", func() (float64, error) { return calc.Permutations(1e18, 2) }, 1e36, nil},
+        {"permutations overflow", func() (float64, error) { return calc.Permutations(1e300, 2) }, 0, ErrOverflow},
+        {"mod", func() (float64, error) { return calc.Mod(7, 3) }, 1, nil},
+        {"mod keeps sign", func() (float64, error) { return calc.Mod(-7, 3) }, -1, nil},
+        {"mod by zero", func() (float64, error) { return calc.Mod(7, 0) }, 0, ErrDivisionByZero},
+        {"floor", func() (float64, error) { return calc.Floor(-2.5) }, -3, nil},
+        {"ceil", func() (float64, error) { return calc.Ceil(-2.5) }, -2, nil},
+        {"round", func() (float64, error) { return calc.Round(3.14159, 2) }, 3.14, nil},
+        {"round half away from zero", func() (float64, error) { return calc.Round(-2.5, 0) }, -3, nil},
+        {"round to tens", func() (float64, error) { return calc.Round(1234, -1) }, 1230, nil},
+        {"round with fractional digits", func() (float64, error) { return calc.Round(1, 0.5) }, 0, ErrDomain},
+        {"round zero to many digits", func() (float64, error) { return calc.Round(0, 400) }, 0, nil},
+        {"round to many digits", func() (float64, error) { return calc.Round(1.5, 400) }, 1.5, nil},
+        {"NaN input", func() (float64, error) { return calc.Sqrt(math.NaN()) }, 0, ErrNaN},
+        {"Inf input", func() (float64, error) { return calc.Sin(math.Inf(1)) }, 0, ErrInfinite},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op()
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > 1e-12*math.Max(1, math.Abs(tt.expected)) {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestDegreeMode(t *testing.T) {
+    calc := NewCalculator()
+    calc.SetAngleMode(Degrees)
+    if calc.AngleMode() != Degrees {
+        t.Fatalf("expected degree mode, got %v", calc.AngleMode())
+    }
+
+    tests := []struct {
+        name     string
+        op       func(float64) (float64, error)
+        x        float64
+        expected float64
+        wantErr  error
+    }{
+        {"sin 30", calc.Sin, 30, 0.5, nil},
+        {"sin 180 is exact", calc.Sin, 180, 0, nil},
+        {"sin -90", calc.Sin, -90, -1, nil},
+        {"cos 90 is exact", calc.Cos, 90, 0, nil},
+        {"cos 360", calc.Cos, 360, 1, nil},
+        {"tan 45", calc.Tan, 45, 1, nil},
+        {"tan 180", calc.Tan, 180, 0, nil},
+        {"tan 90", calc.Tan, 90, 0, ErrDomain},
+        {"tan -270", calc.Tan, -270, 0, ErrDomain},
+        {"asin", calc.Asin, 0.5, 30, nil},
+        {"acos", calc.Acos, 0, 90, nil},
+        {"atan", calc.Atan, 1, 45, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op(tt.x)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > 1e-12 {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestScientificHistoryAndExpressions(t *testing.T) {
+    calc := NewCalculator()
+    calc.Sqrt(16)
+    calc.Mod(7, 3)
+    calc.Combinations(5, 2)
+    calc.Sqrt(-1)
+
+    history := calc.History()
+    expected := []string{
+        "sqrt(16.000000) = 4.000000",
+        "7.000000 % 3.000000 = 1.000000",
+        "ncr(5.000000, 2.000000) = 10.000000",
+        "sqrt(-1.000000) = error: sqrt(-1): invalid operation: argument outside function domain",
+    }
+    for i, want := range expected {
+        if got := history[i].String(); got != want {
+            t.Errorf("entry %d = %q, want %q", i, got, want)
+        }
+    }
+
+    result, err := calc.Evaluate("sqrt(16) + 10 % 4 * fact(3) - round(2.567, 1)")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if math.Abs(result-13.4) > 1e-12 {
+        t.Errorf("Evaluate = %v, want 13.4", result)
+    }
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "reflect"
+    "strings"
+)
+
+// SessionHeader names the session a request runs in; requests without it share DefaultSession
+const SessionHeader = "X-Calculator-Session"
+
+// DefaultSession is used when a request has no SessionHeader
+const DefaultSession = "default"
+
+// MaxRequestBytes limits the size of request bodies
+const MaxRequestBytes = 1 << 20
+
+// EvalRequest is the body of POST /eval
+type EvalRequest struct {
+    Expression string             `json:"expression"`
+    Variables  map[string]float64 `json:"variables,omitempty"`
+}
+
+// OpRequest is the body of POST /op/{name}
+type OpRequest struct {
+    Operands []float64 `json:"operands"`
+}
+
+// ResultResponse is returned by POST /eval and POST /op/{name}
+type ResultResponse struct {
+    Result float64 `json:"result"`
+}
+
+// HistoryResponse is returned by GET /history
+type HistoryResponse struct {
+    Entries History `json:"entries"`
+}
+
+// ErrorResponse is returned with every 4xx and 5xx status
+type ErrorResponse struct {
+    Error string `json:"error"`
+    Code  string `json:"code"`
+}
+
+// Errors for request bodies that are not valid JSON for the endpoint or are too large
+var (
+    errInvalidRequest  = errors.New("invalid request")
+    errRequestTooLarge = errors.New("request body too large")
+)
+
+// errorStatuses maps calculator errors to HTTP statuses: bad input is 400,
+// a well-formed calculation that cannot be carried out is 422
+var errorStatuses = []struct {
+    err    error
+    status int
+    code   string
+}{
+    {errInvalidRequest, http.StatusBadRequest, "invalid_request"},
+    {errRequestTooLarge, http.StatusRequestEntityTooLarge, "request_too_large"},
+    {ErrNaN, http.StatusBadRequest, "nan_input"},
+    {ErrInfinite, http.StatusBadRequest, "infinite_input"},
+    {ErrSyntax, http.StatusBadRequest, "syntax_error"},
+    {ErrUnknownVariable, http.StatusBadRequest, "unknown_variable"},
+    {ErrInvalidOperator, http.StatusBadRequest, "invalid_operands"},
+    {ErrUnknownOperator, http.StatusNotFound, "unknown_operator"},
+    {ErrDivisionByZero, http.StatusUnprocessableEntity, "division_by_zero"},
+    {ErrNegativeBase, http.StatusUnprocessableEntity, "negative_base"},
+    {ErrDomain, http.StatusUnprocessableEntity, "domain_error"},
+    {ErrOverflow, http.StatusUnprocessableEntity, "overflow"},
+}
+
+// Server exposes calculator sessions over HTTP with JSON bodies
+type Server struct {
+    sessions *SessionManager
+    mux      *http.ServeMux
+}
+
+// NewServer creates a server backed by sessions; a nil manager gets a fresh one
+func NewServer(sessions *SessionManager) *Server {
+    if sessions == nil {
+        sessions = NewSessionManager()
+    }
+    s := &Server{sessions: sessions, mux: http.NewServeMux()}
+    s.mux.HandleFunc("POST /eval", s.handleEval)
+    s.mux.HandleFunc("POST /op/{name}", s.handleOp)
+    s.mux.HandleFunc("GET /history", s.handleHistory)
+    s.mux.HandleFunc("DELETE /history", s.handleClearHistory)
+    return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    s.mux.ServeHTTP(w, r)
+}
+
+// session returns the session named by the request header
+func (s *Server) session(r *http.Request) *Session {
+    name := r.Header.Get(SessionHeader)
+    if name == "" {
+        name = DefaultSession
+    }
+    return s.sessions.Get(name)
+}
+
+func (s *Server) handleEval(w http.ResponseWriter, r *http.Request) {
+    var req EvalRequest
+    if err := decode(w, r, &req); err != nil {
+        writeError(w, err)
+        return
+    }
+    result, err := s.session(r).EvaluateWith(req.Expression, req.Variables)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, ResultResponse{Result: result})
+}
+
+func (s *Server) handleOp(w http.ResponseWriter, r *http.Request) {
+    var req OpRequest
+    if err := decode(w, r, &req); err != nil {
+        writeError(w, err)
+        return
+    }
+    calc := s.session(r).Calculator()
+    name := r.PathValue("name")
+    if _, ok := calc.registry.Lookup(name); !ok {
+        writeError(w, fmt.Errorf("%w %q", ErrUnknownOperator, name))
+        return
+    }
+    result, err := calc.Apply(name, req.Operands...)
+    if err != nil {
+        writeError(w, err)
+        return
+    }
+    writeJSON(w, http.StatusOK, ResultResponse{Result: result})
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, HistoryResponse{Entries: s.session(r).Calculator().History()})
+}
+
+func (s *Server) handleClearHistory(w http.ResponseWriter, r *http.Request) {
+    s.session(r).Calculator().ClearHistory()
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads a JSON request body of at most MaxRequestBytes into v
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+    decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes))
+    decoder.DisallowUnknownFields()
+    err := decoder.Decode(v)
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        return fmt.Errorf("%w: limit is %d bytes", errRequestTooLarge, tooLarge.Limit)
+    }
+    var typeErr *json.UnmarshalTypeError
+    if errors.As(err, &typeErr) && strings.HasPrefix(typeErr.Value, "number ") && typeErr.Type.Kind() == reflect.Float64 {
+        // JSON has no NaN or Inf, but numbers like 1e999 overflow float64
+        return fmt.Errorf("%w: %s", ErrInfinite, typeErr.Value)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %v", errInvalidRequest, err)
+    }
+    return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+    status, code := http.StatusInternalServerError, "internal_error"
+    for _, mapping := range errorStatuses {
+        if errors.Is(err, mapping.err) {
+            status, code = mapping.status, mapping.code
+            break
+        }
+    }
+    writeJSON(w, status, ErrorResponse{Error: err.Error(), Code: code})
+}
+
+// writeJSON encodes v before writing the status, so a value that cannot be
+// encoded gets a 500 instead of a 200 with an empty body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+    var buf bytes.Buffer
+    if err := json.NewEncoder(&buf).Encode(v); err != nil {
+        buf.Reset()
+        status = http.StatusInternalServerError
+        json.NewEncoder(&buf).Encode(ErrorResponse{Error: "cannot encode response: " + err.Error(), Code: "internal_error"})
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    w.Write(buf.Bytes())
+}
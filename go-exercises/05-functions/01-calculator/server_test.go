@@ -0,0 +1,170 @@
+package main
+
+import (
+    "encoding/json"
+    "math"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+// do sends a request to the server and returns the recorded response
+func do(t *testing.T, server http.Handler, method, path, session, body string) *httptest.ResponseRecorder {
+    t.Helper()
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    if session != "" {
+        req.Header.Set(SessionHeader, session)
+    }
+    rec := httptest.NewRecorder()
+    server.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestServerEndpoints(t *testing.T) {
+    server := NewServer(nil)
+    tests := []struct {
+        name       string
+        method     string
+        path       string
+        body       string
+        wantStatus int
+        wantResult float64
+        wantCode   string
+    }{
+        {"eval", "POST", "/eval", `{"expression": "2 + 3 * 4"}`, 200, 14, ""},
+        {"eval with variables", "POST", "/eval", `{"expression": "x ^ 2", "variables": {"x": 3}}`, 200, 9, ""},
+        {"eval division by zero", "POST", "/eval", `{"expression": "1 / 0"}`, 422, 0, "division_by_zero"},
+        {"eval syntax error", "POST", "/eval", `{"expression": "2 +"}`, 400, 0, "syntax_error"},
+        {"eval unknown variable", "POST", "/eval", `{"expression": "y + 1"}`, 400, 0, "unknown_variable"},
+        {"eval domain error", "POST", "/eval", `{"expression": "sqrt(-1)"}`, 422, 0, "domain_error"},
+        {"eval overflow", "POST", "/eval", `{"expression": "10 ^ 400"}`, 422, 0, "overflow"},
+        {"op by name", "POST", "/op/add", `{"operands": [2, 3]}`, 200, 5, ""},
+        {"op by symbol", "POST", "/op/*", `{"operands": [2, 3]}`, 200, 6, ""},
+        {"unary op", "POST", "/op/sqrt", `{"operands": [16]}`, 200, 4, ""},
+        {"op division by zero", "POST", "/op/divide", `{"operands": [1, 0]}`, 422, 0, "division_by_zero"},
+        {"op negative base", "POST", "/op/power", `{"operands": [-8, 0.5]}`, 422, 0, "negative_base"},
+        {"op infinite operand", "POST", "/op/add", `{"operands": [1e400, 1]}`, 400, 0, "infinite_input"},
+        {"op wrong arity", "POST", "/op/add", `{"operands": [1]}`, 400, 0, "invalid_operands"},
+        {"unknown op", "POST", "/op/frobnicate", `{"operands": [1]}`, 404, 0, "unknown_operator"},
+        {"malformed JSON", "POST", "/eval", `{"expression": `, 400, 0, "invalid_request"},
+        {"unknown field", "POST", "/op/add", `{"operands": [1, 2], "extra": true}`, 400, 0, "invalid_request"},
+        {"wrong type", "POST", "/eval", `{"expression": 5}`, 400, 0, "invalid_request"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := do(t, server, tt.method, tt.path, "", tt.body)
+            if rec.Code != tt.wantStatus {
+                t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+            }
+            if got := rec.Header().Get("Content-Type"); got != "application/json" {
+                t.Errorf("Content-Type = %q", got)
+            }
+            if tt.wantCode != "" {
+                var resp ErrorResponse
+                if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                    t.Fatalf("bad error body %q: %v", rec.Body, err)
+                }
+                if resp.Code != tt.wantCode || resp.Error == "" {
+                    t.Errorf("got %+v, want code %q", resp, tt.wantCode)
+                }
+                return
+            }
+            var resp ResultResponse
+            if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                t.Fatalf("bad result body %q: %v", rec.Body, err)
+            }
+            if resp.Result != tt.wantResult {
+                t.Errorf("result %v, want %v", resp.Result, tt.wantResult)
+            }
+        })
+    }
+}
+
+func TestServerMethodNotAllowed(t *testing.T) {
+    server := NewServer(nil)
+    if rec := do(t, server, "GET", "/eval", "", ""); rec.Code != http.StatusMethodNotAllowed {
+        t.Errorf("GET /eval: status %d, want 405", rec.Code)
+    }
+    if rec := do(t, server, "PUT", "/history", "", ""); rec.Code != http.StatusMethodNotAllowed {
+        t.Errorf("PUT /history: status %d, want 405", rec.Code)
+    }
+}
+
+func TestServerSessions(t *testing.T) {
+    sessions := NewSessionManager()
+    server := NewServer(sessions)
+
+    do(t, server, "POST", "/op/add", "alice", `{"operands": [1, 2]}`)
+    do(t, server, "POST", "/eval", "alice", `{"expression": "1 / 0"}`)
+    do(t, server, "POST", "/op/multiply", "bob", `{"operands": [3, 4]}`)
+    do(t, server, "POST", "/op/subtract", "", `{"operands": [5, 1]}`)
+
+    history := func(session string) History {
+        t.Helper()
+        rec := do(t, server, "GET", "/history", session, "")
+        if rec.Code != http.StatusOK {
+            t.Fatalf("GET /history: status %d", rec.Code)
+        }
+        var resp HistoryResponse
+        if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+            t.Fatalf("bad history body %q: %v", rec.Body, err)
+        }
+        return resp.Entries
+    }
+
+    alice := history("alice")
+    if len(alice) != 2 || alice[0].String() != "1.000000 + 2.000000 = 3.000000" {
+        t.Fatalf("alice's history = %v", alice.Strings())
+    }
+    if alice[1].Err == nil || !strings.Contains(alice[1].Err.Error(), "division by zero") {
+        t.Errorf("failed evaluation not recorded: %v", alice[1])
+    }
+    if bob := history("bob"); len(bob) != 1 || bob[0].Result != 12 {
+        t.Errorf("bob's history = %v", bob.Strings())
+    }
+    if def := history(""); len(def) != 1 || def[0].Result != 4 {
+        t.Errorf("default history = %v", def.Strings())
+    }
+    if _, ok := sessions.Lookup(DefaultSession); !ok {
+        t.Errorf("requests without %s should use %q", SessionHeader, DefaultSession)
+    }
+
+    if rec := do(t, server, "DELETE", "/history", "alice", ""); rec.Code != http.StatusNoContent {
+        t.Errorf("DELETE /history: status %d, want 204", rec.Code)
+    }
+    if alice := history("alice"); len(alice) != 0 {
+        t.Errorf("alice's history not cleared: %v", alice.Strings())
+    }
+    if bob := history("bob"); len(bob) != 1 {
+        t.Errorf("clearing alice's history cleared bob's: %v", bob.Strings())
+    }
+}
+
+func TestServerLimits(t *testing.T) {
+    server := NewServer(nil)
+    body := `{"expression": "` + strings.Repeat("1 + ", MaxRequestBytes/4) + `1"}`
+    rec := do(t, server, "POST", "/eval", "", body)
+    var resp ErrorResponse
+    if rec.Code != http.StatusRequestEntityTooLarge || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.Code != "request_too_large" {
+        t.Errorf("oversized body: status %d, body %s", rec.Code, rec.Body)
+    }
+
+    // rounding zero to many digits once stored NaN, which broke GET /history
+    if rec := do(t, server, "POST", "/op/round", "", `{"operands": [0, 400]}`); rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+        t.Errorf("POST /op/round: status %d, body %q", rec.Code, rec.Body)
+    }
+    if rec := do(t, server, "GET", "/history", "", ""); rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+        t.Errorf("GET /history: status %d, body %q", rec.Code, rec.Body)
+    }
+}
+
+func TestWriteJSONEncodingFailure(t *testing.T) {
+    rec := httptest.NewRecorder()
+    writeJSON(rec, http.StatusOK, ResultResponse{Result: math.NaN()})
+    var resp ErrorResponse
+    if rec.Code != http.StatusInternalServerError || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.Code != "internal_error" {
+        t.Errorf("status %d, body %q; want a 500 error body", rec.Code, rec.Body)
+    }
+}
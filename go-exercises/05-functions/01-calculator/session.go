@@ -0,0 +1,335 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+)
+
+// Session is an accumulator-style scratchpad on top of a Calculator.
+// Each operation applies to the current value and can be undone or redone.
+// A Session is safe for concurrent use; each operation is applied atomically.
+type Session struct {
+    mu    sync.Mutex
+    calc  *Calculator
+    value float64
+    undo  []sessionStep
+    redo  []sessionStep
+}
+
+// sessionStep remembers the value before and after one successful operation
+type sessionStep struct {
+    before, after float64
+}
+
+// ReplayMismatch describes a replayed history entry whose outcome differs from the
+// recording, or an entry that was skipped because it cannot be replayed
+type ReplayMismatch struct {
+    Index   int
+    Entry   HistoryEntry
+    Result  float64
+    Err     error
+    Skipped bool // the entry was not re-executed; Err says why
+}
+
+func (m ReplayMismatch) String() string {
+    if m.Skipped {
+        return fmt.Sprintf("step %d: %s, skipped: %v", m.Index, m.Entry, m.Err)
+    }
+    if m.Err != nil {
+        return fmt.Sprintf("step %d: %s, replay failed: %v", m.Index, m.Entry, m.Err)
+    }
+    return fmt.Sprintf("step %d: %s, replay got %f", m.Index, m.Entry, m.Result)
+}
+
+// NewSession creates a session starting at zero.
+// Operations are recorded in calc's history; a nil calc gets a fresh Calculator.
+func NewSession(calc *Calculator) *Session {
+    if calc == nil {
+        calc = NewCalculator()
+    }
+    return &Session{calc: calc}
+}
+
+// Value returns the current accumulator value
+func (s *Session) Value() float64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.value
+}
+
+// Calculator returns the calculator that records the session's history
+func (s *Session) Calculator() *Calculator {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.calc
+}
+
+// Add adds x to the current value
+func (s *Session) Add(x float64) (float64, error) {
+    return s.apply("+", x)
+}
+
+// Subtract subtracts x from the current value
+func (s *Session) Subtract(x float64) (float64, error) {
+    return s.apply("-", x)
+}
+
+// Multiply multiplies the current value by x
+func (s *Session) Multiply(x float64) (float64, error) {
+    return s.apply("*", x)
+}
+
+// Divide divides the current value by x
+func (s *Session) Divide(x float64) (float64, error) {
+    return s.apply("/", x)
+}
+
+// Power raises the current value to the power of x
+func (s *Session) Power(x float64) (float64, error) {
+    return s.apply("^", x)
+}
+
+// Evaluate evaluates an expression and makes its result the current value
+func (s *Session) Evaluate(expr string) (float64, error) {
+    return s.EvaluateWith(expr, nil)
+}
+
+// EvaluateWith is Evaluate for expressions that refer to variables
+func (s *Session) EvaluateWith(expr string, vars map[string]float64) (float64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    result, err := s.calc.EvaluateWith(expr, vars)
+    if err != nil {
+        return s.value, err
+    }
+    s.push(result)
+    return result, nil
+}
+
+// Undo restores the value from before the last successful operation
+func (s *Session) Undo() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if len(s.undo) == 0 {
+        return ErrNothingToUndo
+    }
+    step := s.undo[len(s.undo)-1]
+    s.undo = s.undo[:len(s.undo)-1]
+    s.redo = append(s.redo, step)
+    s.value = step.before
+    return nil
+}
+
+// Redo reapplies the last undone operation
+func (s *Session) Redo() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if len(s.redo) == 0 {
+        return ErrNothingToRedo
+    }
+    step := s.redo[len(s.redo)-1]
+    s.redo = s.redo[:len(s.redo)-1]
+    s.undo = append(s.undo, step)
+    s.value = step.after
+    return nil
+}
+
+// Replay re-executes a recorded history against a fresh calculator, which keeps this
+// session's angle mode, registered operators and units, and reports every entry whose
+// result or error differs from the recording. Only scalar operators and expressions
+// without variables can be re-executed: entries from the other views, expressions that
+// used variables and operators that are not registered are carried over unchanged and
+// reported as skipped, with an error wrapping ErrNotReplayable or ErrUnknownOperator.
+// Afterwards the session holds the replayed history and the result of its last
+// successful re-executed entry.
+func (s *Session) Replay(history History) []ReplayMismatch {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    fresh := NewCalculator()
+    fresh.now = s.calc.now
+    fresh.units = s.calc.units
+    fresh.SetAngleMode(s.calc.AngleMode())
+    for _, op := range s.calc.Operators() {
+        if _, ok := fresh.registry.Lookup(op.Name); !ok {
+            fresh.registry.Register(op)
+        }
+    }
+    s.calc, s.value, s.undo, s.redo = fresh, 0, nil, nil
+
+    var mismatches []ReplayMismatch
+    for i, entry := range history {
+        if err := s.replayable(entry); err != nil {
+            s.calc.record(entry)
+            mismatches = append(mismatches, ReplayMismatch{Index: i, Entry: entry, Err: err, Skipped: true})
+            continue
+        }
+        result, err := s.replayEntry(entry)
+        if err == nil {
+            s.push(result)
+        }
+        if (err == nil) != (entry.Err == nil) || (err == nil && result != entry.Result) {
+            mismatches = append(mismatches, ReplayMismatch{Index: i, Entry: entry, Result: result, Err: err})
+        }
+    }
+    return mismatches
+}
+
+// replayable reports why an entry cannot be re-executed, or nil if it can
+func (s *Session) replayable(entry HistoryEntry) error {
+    switch {
+    case entry.OperandText != nil || entry.ResultText != "":
+        return fmt.Errorf("%w: %q was recorded by another view", ErrNotReplayable, entry.Operator)
+    case entry.Operator == "eval":
+        if expr, err := Parse(entry.Expression); err == nil && usesVariables(expr) {
+            return fmt.Errorf("%w: %q uses variables that were not recorded", ErrNotReplayable, entry.Expression)
+        }
+        return nil
+    case entry.Expression != "":
+        return fmt.Errorf("%w: %q was recorded by another view", ErrNotReplayable, entry.Operator)
+    }
+    if _, ok := s.calc.registry.Lookup(entry.Operator); !ok {
+        return fmt.Errorf("%w %q", ErrUnknownOperator, entry.Operator)
+    }
+    return nil
+}
+
+func (s *Session) replayEntry(entry HistoryEntry) (float64, error) {
+    if entry.Operator == "eval" {
+        return s.calc.Evaluate(entry.Expression)
+    }
+    return s.calc.Apply(entry.Operator, entry.Operands...)
+}
+
+// usesVariables reports whether e refers to any variable
+func usesVariables(e Expr) bool {
+    switch n := e.(type) {
+    case *VariableExpr:
+        return true
+    case *UnaryExpr:
+        return usesVariables(n.Operand)
+    case *BinaryExpr:
+        return usesVariables(n.Left) || usesVariables(n.Right)
+    case *CallExpr:
+        for _, arg := range n.Args {
+            if usesVariables(arg) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// apply runs a binary operator with the current value as the left operand
+func (s *Session) apply(op string, x float64) (float64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    result, err := s.calc.Apply(op, s.value, x)
+    if err != nil {
+        return s.value, err
+    }
+    s.push(result)
+    return result, nil
+}
+
+// push makes result the current value and starts a new branch of the undo history
+func (s *Session) push(result float64) {
+    s.undo = append(s.undo, sessionStep{before: s.value, after: result})
+    s.redo = nil
+    s.value = result
+}
+
+// DefaultMaxSessions is the number of sessions a SessionManager keeps by default
+const DefaultMaxSessions = 1000
+
+// SessionManager keeps named sessions, each with its own Calculator, so one process
+// can serve many independent users. Once it holds its limit of sessions, creating
+// another evicts the least recently used one. It is safe for concurrent use.
+type SessionManager struct {
+    mu       sync.Mutex
+    sessions map[string]*managedSession
+    limit    int
+    clock    uint64 // counts uses, to find the least recently used session
+}
+
+type managedSession struct {
+    session  *Session
+    lastUsed uint64
+}
+
+// NewSessionManager creates a manager without sessions that keeps up to DefaultMaxSessions
+func NewSessionManager() *SessionManager {
+    return &SessionManager{sessions: make(map[string]*managedSession), limit: DefaultMaxSessions}
+}
+
+// SetLimit changes the number of sessions kept, evicting the least recently used
+// ones if there are more; limits below 1 are treated as 1
+func (m *SessionManager) SetLimit(limit int) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.limit = max(limit, 1)
+    for len(m.sessions) > m.limit {
+        m.evict()
+    }
+}
+
+// Get returns the named session, creating it with a fresh Calculator on first use
+func (m *SessionManager) Get(name string) *Session {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.clock++
+    ms, ok := m.sessions[name]
+    if !ok {
+        if len(m.sessions) >= m.limit {
+            m.evict()
+        }
+        ms = &managedSession{session: NewSession(nil)}
+        m.sessions[name] = ms
+    }
+    ms.lastUsed = m.clock
+    return ms.session
+}
+
+// evict removes the least recently used session; m.mu must be held
+func (m *SessionManager) evict() {
+    var oldest string
+    oldestUse := ^uint64(0)
+    for name, ms := range m.sessions {
+        if ms.lastUsed < oldestUse {
+            oldest, oldestUse = name, ms.lastUsed
+        }
+    }
+    delete(m.sessions, oldest)
+}
+
+// Lookup returns the named session if it exists
+func (m *SessionManager) Lookup(name string) (*Session, bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    ms, ok := m.sessions[name]
+    if !ok {
+        return nil, false
+    }
+    return ms.session, true
+}
+
+// Delete removes the named session and reports whether it existed
+func (m *SessionManager) Delete(name string) bool {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    _, ok := m.sessions[name]
+    delete(m.sessions, name)
+    return ok
+}
+
+// Names returns the names of all sessions in sorted order
+func (m *SessionManager) Names() []string {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    names := make([]string, 0, len(m.sessions))
+    for name := range m.sessions {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+)
+
+func TestSessionAccumulates(t *testing.T) {
+    s := NewSession(nil)
+    steps := []struct {
+        name     string
+        apply    func(float64) (float64, error)
+        operand  float64
+        expected float64
+        wantErr  bool
+    }{
+        {"add", s.Add, 10, 10, false},
+        {"multiply", s.Multiply, 3, 30, false},
+        {"subtract", s.Subtract, 5, 25, false},
+        {"divide by zero keeps value", s.Divide, 0, 25, true},
+        {"divide", s.Divide, 5, 5, false},
+        {"power", s.Power, 2, 25, false},
+    }
+
+    for _, step := range steps {
+        result, err := step.apply(step.operand)
+        if step.wantErr != (err != nil) {
+            t.Fatalf("%s: unexpected error state: %v", step.name, err)
+        }
+        if result != step.expected || s.Value() != step.expected {
+            t.Fatalf("%s: got %v (value %v), want %v", step.name, result, s.Value(), step.expected)
+        }
+    }
+
+    if got := len(s.Calculator().History()); got != len(steps) {
+        t.Errorf("expected %d history entries, got %d", len(steps), got)
+    }
+}
+
+func TestSessionUndoRedo(t *testing.T) {
+    s := NewSession(nil)
+    s.Add(5)
+    s.Multiply(4)
+    s.Evaluate("2 + 2")
+
+    if err := s.Undo(); err != nil {
+        t.Fatalf("Undo failed: %v", err)
+    }
+    if s.Value() != 20 {
+        t.Errorf("after first undo value = %v, want 20", s.Value())
+    }
+    s.Undo()
+    if s.Value() != 5 {
+        t.Errorf("after second undo value = %v, want 5", s.Value())
+    }
+    if err := s.Redo(); err != nil {
+        t.Fatalf("Redo failed: %v", err)
+    }
+    if s.Value() != 20 {
+        t.Errorf("after redo value = %v, want 20", s.Value())
+    }
+
+    // a new operation discards the redo stack
+    s.Subtract(1)
+    if err := s.Redo(); !errors.Is(err, ErrNothingToRedo) {
+        t.Errorf("expected ErrNothingToRedo after a new operation, got %v", err)
+    }
+
+    s.Undo()
+    s.Undo()
+    s.Undo()
+    if s.Value() != 0 {
+        t.Errorf("value after undoing everything = %v, want 0", s.Value())
+    }
+    if err := s.Undo(); !errors.Is(err, ErrNothingToUndo) {
+        t.Errorf("expected ErrNothingToUndo, got %v", err)
+    }
+}
+
+func TestSessionReplay(t *testing.T) {
+    original := NewSession(nil)
+    original.Add(2)
+    original.Multiply(10)
+    original.Divide(0)
+    original.Evaluate("7 * 6")
+
+    history := original.Calculator().History()
+    replayed := NewSession(nil)
+    if mismatches := replayed.Replay(history); len(mismatches) != 0 {
+        t.Errorf("expected no mismatches, got %v", mismatches)
+    }
+    if replayed.Value() != 42 {
+        t.Errorf("replayed value = %v, want 42", replayed.Value())
+    }
+    if got := len(replayed.Calculator().History()); got != len(history) {
+        t.Errorf("replayed history has %d entries, want %d", got, len(history))
+    }
+    if err := replayed.Undo(); err != nil || replayed.Value() != 20 {
+        t.Errorf("undo after replay: value %v, err %v", replayed.Value(), err)
+    }
+
+    // tamper with the recording
+    history[1].Result = 21
+    history[2].Err = nil
+    history = append(history, HistoryEntry{Operator: "?", Operands: []float64{1, 2}})
+    mismatches := NewSession(nil).Replay(history)
+    if len(mismatches) != 3 {
+        t.Fatalf("expected 3 mismatches, got %v", mismatches)
+    }
+    if mismatches[0].Index != 1 || mismatches[0].Result != 20 {
+        t.Errorf("unexpected first mismatch: %v", mismatches[0])
+    }
+    if mismatches[1].Index != 2 || mismatches[1].Err == nil {
+        t.Errorf("unexpected second mismatch: %v", mismatches[1])
+    }
+    if mismatches[2].Index != 4 || !mismatches[2].Skipped || !errors.Is(mismatches[2].Err, ErrUnknownOperator) {
+        t.Errorf("unexpected third mismatch: %v", mismatches[2])
+    }
+}
+
+func TestSessionReplaySkipsOtherViews(t *testing.T) {
+    calc := NewCalculator()
+    calc.SetAngleMode(Degrees)
+    calc.Sin(90)
+    calc.Mean([]float64{1, 2, 3})
+    calc.Units().Evaluate("3 km + 200 m")
+    calc.EvaluateWith("x * 2", map[string]float64{"x": 4})
+    calc.Evaluate("cos(180)")
+
+    s := NewSession(calc)
+    mismatches := s.Replay(calc.History())
+    if len(mismatches) != 3 {
+        t.Fatalf("expected 3 skipped entries, got %v", mismatches)
+    }
+    for i, m := range mismatches {
+        if m.Index != i+1 || !m.Skipped || !errors.Is(m.Err, ErrNotReplayable) {
+            t.Errorf("expected entry %d to be skipped as not replayable, got %v", i+1, m)
+        }
+    }
+    if s.Value() != -1 {
+        t.Errorf("replayed value = %v, want -1", s.Value())
+    }
+    if got := len(s.Calculator().History()); got != 5 {
+        t.Errorf("replayed history has %d entries, want 5", got)
+    }
+
+    // trigonometric functions follow the replaying calculator's angle mode
+    calc.SetAngleMode(Radians)
+    if result, err := s.Calculator().Sin(90); err != nil || result != 1 {
+        t.Errorf("Sin(90) after replay = %v, %v, want 1 in degrees", result, err)
+    }
+}
+
+func TestSessionManager(t *testing.T) {
+    m := NewSessionManager()
+    alice := m.Get("alice")
+    alice.Add(5)
+    if again := m.Get("alice"); again != alice {
+        t.Fatal("Get returned a different session for the same name")
+    }
+    bob := m.Get("bob")
+    bob.Add(1)
+    if bob.Calculator() == alice.Calculator() {
+        t.Fatal("sessions share a calculator")
+    }
+    if alice.Value() != 5 || len(alice.Calculator().History()) != 1 {
+        t.Errorf("bob's operation leaked into alice's session: value %v, history %v", alice.Value(), alice.Calculator().History())
+    }
+    if names := m.Names(); !reflect.DeepEqual(names, []string{"alice", "bob"}) {
+        t.Errorf("Names() = %v", names)
+    }
+
+    if !m.Delete("alice") || m.Delete("alice") {
+        t.Error("Delete should report whether the session existed")
+    }
+    if _, ok := m.Lookup("alice"); ok {
+        t.Error("deleted session is still there")
+    }
+    if s, ok := m.Lookup("bob"); !ok || s != bob {
+        t.Error("Lookup did not find bob")
+    }
+    if m.Get("alice").Value() != 0 {
+        t.Error("recreated session should start from zero")
+    }
+}
+
+func TestSessionManagerLimit(t *testing.T) {
+    m := NewSessionManager()
+    m.SetLimit(2)
+    alice := m.Get("alice")
+    m.Get("bob")
+    m.Get("alice") // bob is now the least recently used
+    m.Get("carol")
+    if names := m.Names(); !reflect.DeepEqual(names, []string{"alice", "carol"}) {
+        t.Errorf("Names() = %v, want bob evicted", names)
+    }
+    if m.Get("alice") != alice {
+        t.Error("recently used session was evicted")
+    }
+
+    m.SetLimit(1)
+    if names := m.Names(); !reflect.DeepEqual(names, []string{"alice"}) {
+        t.Errorf("Names() after lowering the limit = %v", names)
+    }
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "slices"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Errors returned by the dataset methods in addition to ErrNaN, ErrInfinite and ErrOverflow
+var (
+    ErrEmptyDataset      = errors.New("invalid input: empty dataset")
+    ErrDimensionMismatch = errors.New("invalid input: dimension mismatch")
+)
+
+// maxSummaryValues is the largest dataset written out in full in the history
+const maxSummaryValues = 5
+
+// Regression is a least-squares line y = Slope*x + Intercept
+type Regression struct {
+    Slope     float64
+    Intercept float64
+    RSquared  float64 // coefficient of determination, 1 for a perfect fit
+}
+
+func (r Regression) String() string {
+    return fmt.Sprintf("y = %s*x + %s (r² = %s)", formatFloat(r.Slope), formatFloat(r.Intercept), formatFloat(r.RSquared))
+}
+
+// Sum returns the sum of the values
+func (c *Calculator) Sum(data []float64) (float64, error) {
+    return c.dataset("sum", [][]float64{data}, nil, func() (float64, string, error) {
+        total := 0.0
+        for _, x := range data {
+            total += x
+        }
+        return total, "", nil
+    })
+}
+
+// Mean returns the arithmetic mean of the values
+func (c *Calculator) Mean(data []float64) (float64, error) {
+    return c.dataset("mean", [][]float64{data}, nil, func() (float64, string, error) {
+        return mean(data), "", nil
+    })
+}
+
+// Median returns the middle value, or the mean of the two middle values
+func (c *Calculator) Median(data []float64) (float64, error) {
+    return c.dataset("median", [][]float64{data}, nil, func() (float64, string, error) {
+        sorted := sortedCopy(data)
+        mid := len(sorted) / 2
+        if len(sorted)%2 == 1 {
+            return sorted[mid], "", nil
+        }
+        return sorted[mid-1]/2 + sorted[mid]/2, "", nil
+    })
+}
+
+// Mode returns the most frequent values in ascending order; every value is a mode
+// when none repeats
+func (c *Calculator) Mode(data []float64) ([]float64, error) {
+    var modes []float64
+    _, err := c.dataset("mode", [][]float64{data}, nil, func() (float64, string, error) {
+        counts := make(map[float64]int)
+        best := 0
+        for _, x := range data {
+            counts[x]++
+            best = max(best, counts[x])
+        }
+        for x, n := range counts {
+            if n == best {
+                modes = append(modes, x)
+            }
+        }
+        sort.Float64s(modes)
+        return modes[0], summarize(modes), nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return modes, nil
+}
+
+// PopulationVariance returns the variance of the values as a complete population
+func (c *Calculator) PopulationVariance(data []float64) (float64, error) {
+    return c.dataset("pvariance", [][]float64{data}, nil, func() (float64, string, error) {
+        return sumOfSquares(data) / float64(len(data)), "", nil
+    })
+}
+
+// SampleVariance returns the unbiased variance of the values as a sample; it needs at least two values
+func (c *Calculator) SampleVariance(data []float64) (float64, error) {
+    return c.dataset("variance", [][]float64{data}, nil, func() (float64, string, error) {
+        return sampleVariance(data)
+    })
+}
+
+// PopulationStdDev returns the square root of PopulationVariance
+func (c *Calculator) PopulationStdDev(data []float64) (float64, error) {
+    return c.dataset("pstddev", [][]float64{data}, nil, func() (float64, string, error) {
+        return math.Sqrt(sumOfSquares(data) / float64(len(data))), "", nil
+    })
+}
+
+// SampleStdDev returns the square root of SampleVariance
+func (c *Calculator) SampleStdDev(data []float64) (float64, error) {
+    return c.dataset("stddev", [][]float64{data}, nil, func() (float64, string, error) {
+        variance, _, err := sampleVariance(data)
+        return math.Sqrt(variance), "", err
+    })
+}
+
+// Percentile returns the pth percentile (0 ≤ p ≤ 100), interpolating linearly between
+// the closest ranks like a spreadsheet's PERCENTILE.INC
+func (c *Calculator) Percentile(data []float64, p float64) (float64, error) {
+    return c.dataset("percentile", [][]float64{data}, []float64{p}, func() (float64, string, error) {
+        if p < 0 || p > 100 {
+            return 0, "", ErrDomain
+        }
+        sorted := sortedCopy(data)
+        rank := p / 100 * float64(len(sorted)-1)
+        lower := int(math.Floor(rank))
+        if lower == len(sorted)-1 {
+            return sorted[lower], "", nil
+        }
+        frac := rank - float64(lower)
+        return sorted[lower] + frac*(sorted[lower+1]-sorted[lower]), "", nil
+    })
+}
+
+// Min returns the smallest value
+func (c *Calculator) Min(data []float64) (float64, error) {
+    return c.dataset("min", [][]float64{data}, nil, func() (float64, string, error) {
+        return slices.Min(data), "", nil
+    })
+}
+
+// Max returns the largest value
+func (c *Calculator) Max(data []float64) (float64, error) {
+    return c.dataset("max", [][]float64{data}, nil, func() (float64, string, error) {
+        return slices.Max(data), "", nil
+    })
+}
+
+// LinearRegression fits y = slope*x + intercept by least squares. xs and ys must have the
+// same length, and xs needs at least two distinct values.
+func (c *Calculator) LinearRegression(xs, ys []float64) (Regression, error) {
+    var fit Regression
+    _, err := c.dataset("linreg", [][]float64{xs, ys}, nil, func() (float64, string, error) {
+        if len(xs) != len(ys) {
+            return 0, "", fmt.Errorf("%w: %d x values, %d y values", ErrDimensionMismatch, len(xs), len(ys))
+        }
+        mx, my := mean(xs), mean(ys)
+        var sxx, sxy, syy float64
+        for i := range xs {
+            dx, dy := xs[i]-mx, ys[i]-my
+            sxx += dx * dx
+            sxy += dx * dy
+            syy += dy * dy
+        }
+        if sxx == 0 {
+            return 0, "", fmt.Errorf("%w: x values are all equal", ErrDomain)
+        }
+        fit.Slope = sxy / sxx
+        fit.Intercept = my - fit.Slope*mx
+        fit.RSquared = 1
+        if syy != 0 {
+            fit.RSquared = sxy * sxy / (sxx * syy)
+        }
+        if math.IsInf(fit.Slope, 0) || math.IsInf(fit.Intercept, 0) {
+            return math.Inf(1), "", nil
+        }
+        return fit.Slope, fit.String(), nil
+    })
+    if err != nil {
+        return Regression{}, err
+    }
+    return fit, nil
+}
+
+// dataset validates the datasets and scalar arguments, runs fn and records one summarized
+// history entry. fn returns the result, plus its text when the result is not a single number.
+func (c *Calculator) dataset(name string, datasets [][]float64, args []float64, fn func() (float64, string, error)) (float64, error) {
+    entry := HistoryEntry{Operator: name}
+    for _, values := range datasets {
+        entry.OperandText = append(entry.OperandText, summarize(values))
+    }
+    for _, arg := range args {
+        entry.OperandText = append(entry.OperandText, formatFloat(arg))
+    }
+
+    var result float64
+    var text string
+    err := checkDatasets(datasets, args)
+    if err == nil {
+        result, text, err = fn()
+    }
+    if err == nil && math.IsInf(result, 0) {
+        err = ErrOverflow
+    }
+    if err != nil {
+        err = &CalcError{Op: name, Operands: append([]float64(nil), args...), Err: err}
+        result, text = 0, ""
+    }
+    entry.Result, entry.ResultText, entry.Err = result, text, err
+    c.record(entry)
+    return result, err
+}
+
+// checkDatasets applies the same input checks as the scalar operators
+func checkDatasets(datasets [][]float64, args []float64) error {
+    for _, values := range datasets {
+        if len(values) == 0 {
+            return ErrEmptyDataset
+        }
+    }
+    for _, values := range append(datasets, args) {
+        for _, x := range values {
+            if math.IsNaN(x) {
+                return ErrNaN
+            }
+        }
+    }
+    for _, values := range append(datasets, args) {
+        for _, x := range values {
+            if math.IsInf(x, 0) {
+                return ErrInfinite
+            }
+        }
+    }
+    return nil
+}
+
+// mean uses a running average so that large values do not overflow the sum
+func mean(data []float64) float64 {
+    m := 0.0
+    for i, x := range data {
+        m += (x - m) / float64(i+1)
+    }
+    return m
+}
+
+// sumOfSquares returns the sum of squared deviations from the mean
+func sumOfSquares(data []float64) float64 {
+    m := mean(data)
+    total := 0.0
+    for _, x := range data {
+        total += (x - m) * (x - m)
+    }
+    return total
+}
+
+func sampleVariance(data []float64) (float64, string, error) {
+    if len(data) < 2 {
+        return 0, "", fmt.Errorf("%w: a sample needs at least 2 values", ErrDomain)
+    }
+    return sumOfSquares(data) / float64(len(data)-1), "", nil
+}
+
+func sortedCopy(data []float64) []float64 {
+    sorted := append([]float64(nil), data...)
+    sort.Float64s(sorted)
+    return sorted
+}
+
+// summarize writes a dataset for the history: small sets in full, large ones
+// as their first values and size, e.g. "[1, 2, 3, … n=1000]"
+func summarize(data []float64) string {
+    shown := data
+    if len(data) > maxSummaryValues {
+        shown = data[:3]
+    }
+    values := make([]string, len(shown))
+    for i, x := range shown {
+        values[i] = formatFloat(x)
+    }
+    if len(data) > maxSummaryValues {
+        return fmt.Sprintf("[%s, … n=%d]", strings.Join(values, ", "), len(data))
+    }
+    return "[" + strings.Join(values, ", ") + "]"
+}
+
+func formatFloat(x float64) string {
+    return strconv.FormatFloat(x, 'g', -1, 64)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "reflect"
+    "testing"
+)
+
+func TestStatistics(t *testing.T) {
+    calc := NewCalculator()
+    data := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+    percentile := func(p float64) func([]float64) (float64, error) {
+        return func(xs []float64) (float64, error) { return calc.Percentile(xs, p) }
+    }
+
+    tests := []struct {
+        name     string
+        op       func([]float64) (float64, error)
+        data     []float64
+        expected float64
+        wantErr  error
+    }{
+        {"sum", calc.Sum, data, 40, nil},
+        {"mean", calc.Mean, data, 5, nil},
+        {"mean of huge values", calc.Mean, []float64{1e308, 1e308}, 1e308, nil},
+        {"median of even count", calc.Median, data, 4.5, nil},
+        {"median of odd count", calc.Median, []float64{3, 1, 2}, 2, nil},
+        {"population variance", calc.PopulationVariance, data, 4, nil},
+        {"population stddev", calc.PopulationStdDev, data, 2, nil},
+        {"sample variance", calc.SampleVariance, []float64{1, 2, 3, 4}, 5.0 / 3, nil},
+        {"sample stddev", calc.SampleStdDev, []float64{2, 4}, math.Sqrt2, nil},
+        {"single value population variance", calc.PopulationVariance, []float64{7}, 0, nil},
+        {"single value sample variance", calc.SampleVariance, []float64{7}, 0, ErrDomain},
+        {"0th percentile", percentile(0), data, 2, nil},
+        {"50th percentile", percentile(50), data, 4.5, nil},
+        {"interpolated percentile", percentile(90), []float64{1, 2, 3, 4, 5}, 4.6, nil},
+        {"100th percentile", percentile(100), data, 9, nil},
+        {"percentile out of range", percentile(101), data, 0, ErrDomain},
+        {"NaN percentile", percentile(math.NaN()), data, 0, ErrNaN},
+        {"min", calc.Min, data, 2, nil},
+        {"max", calc.Max, data, 9, nil},
+        {"empty dataset", calc.Mean, nil, 0, ErrEmptyDataset},
+        {"empty dataset for min", calc.Min, []float64{}, 0, ErrEmptyDataset},
+        {"NaN value", calc.Sum, []float64{1, math.NaN()}, 0, ErrNaN},
+        {"infinite value", calc.Max, []float64{1, math.Inf(1)}, 0, ErrInfinite},
+        {"sum overflows", calc.Sum, []float64{math.MaxFloat64, math.MaxFloat64}, 0, ErrOverflow},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op(tt.data)
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > 1e-12*math.Max(1, math.Abs(tt.expected)) {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestMode(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        data     []float64
+        expected []float64
+    }{
+        {"single mode", []float64{1, 2, 2, 3}, []float64{2}},
+        {"tie", []float64{3, 1, 3, 1, 2}, []float64{1, 3}},
+        {"no repeats", []float64{3, 2, 1}, []float64{1, 2, 3}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            modes, err := calc.Mode(tt.data)
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if !reflect.DeepEqual(modes, tt.expected) {
+                t.Errorf("got %v, want %v", modes, tt.expected)
+            }
+        })
+    }
+
+    if _, err := calc.Mode(nil); !errors.Is(err, ErrEmptyDataset) {
+        t.Errorf("expected ErrEmptyDataset, got %v", err)
+    }
+}
+
+func TestLinearRegression(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        xs, ys   []float64
+        expected Regression
+        wantErr  error
+    }{
+        {"perfect fit", []float64{1, 2, 3, 4}, []float64{3, 5, 7, 9}, Regression{Slope: 2, Intercept: 1, RSquared: 1}, nil},
+        {"noisy fit", []float64{1, 2, 3, 4, 5}, []float64{2, 4, 5, 4, 5}, Regression{Slope: 0.6, Intercept: 2.2, RSquared: 0.6}, nil},
+        {"flat line", []float64{1, 2, 3}, []float64{4, 4, 4}, Regression{Slope: 0, Intercept: 4, RSquared: 1}, nil},
+        {"length mismatch", []float64{1, 2, 3}, []float64{1, 2}, Regression{}, ErrDimensionMismatch},
+        {"vertical line", []float64{2, 2, 2}, []float64{1, 2, 3}, Regression{}, ErrDomain},
+        {"empty ys", []float64{1, 2}, nil, Regression{}, ErrEmptyDataset},
+        {"infinite y", []float64{1, 2}, []float64{1, math.Inf(-1)}, Regression{}, ErrInfinite},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fit, err := calc.LinearRegression(tt.xs, tt.ys)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            got := []float64{fit.Slope, fit.Intercept, fit.RSquared}
+            want := []float64{tt.expected.Slope, tt.expected.Intercept, tt.expected.RSquared}
+            for i := range got {
+                if math.Abs(got[i]-want[i]) > 1e-12 {
+                    t.Errorf("got %+v, want %+v", fit, tt.expected)
+                    break
+                }
+            }
+        })
+    }
+}
+
+func TestStatisticsHistory(t *testing.T) {
+    calc := NewCalculator()
+    large := make([]float64, 1000)
+    for i := range large {
+        large[i] = float64(i + 1)
+    }
+    calc.Mean([]float64{1, 2, 3})
+    calc.Sum(large)
+    calc.Percentile([]float64{1, 2, 3, 4, 5}, 90)
+    calc.Mode([]float64{1, 1, 2, 2})
+    calc.LinearRegression([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+    calc.Mean(nil)
+
+    entries := calc.History()
+    want := []string{
+        "mean([1, 2, 3]) = 2.000000",
+        "sum([1, 2, 3, … n=1000]) = 500500.000000",
+        "percentile([1, 2, 3, 4, 5], 90) = 4.600000",
+        "mode([1, 1, 2, 2]) = [1, 2]",
+        "linreg([1, 2, 3, 4], [3, 5, 7, 9]) = y = 2*x + 1 (r² = 1)",
+        "mean([]) = error: mean(): invalid input: empty dataset",
+    }
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
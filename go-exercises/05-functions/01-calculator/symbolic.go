@@ -0,0 +1,407 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "strings"
+)
+
+// ErrNotDifferentiable is returned for functions without a derivative rule, such as floor
+var ErrNotDifferentiable = errors.New("invalid operation: not differentiable")
+
+// Differentiate returns the simplified derivative of e with respect to variable.
+// Trigonometric functions are taken to work in radians.
+func Differentiate(e Expr, variable string) (Expr, error) {
+    d := differentiator{variable: variable, mode: Radians}
+    derivative, err := d.derive(e)
+    if err != nil {
+        return nil, err
+    }
+    return Simplify(derivative), nil
+}
+
+// Differentiate parses expr and returns its simplified derivative with respect to
+// variable, using the calculator's angle mode for trigonometric functions. It is
+// recorded as "d/dx(x ^ 2) = 2 * x".
+func (c *Calculator) Differentiate(expr, variable string) (Expr, error) {
+    derivative, err := c.differentiate(expr, variable)
+    entry := HistoryEntry{Operator: "d/d" + variable, Expression: derivativeLabel(expr, variable), Err: err}
+    if err == nil {
+        entry.ResultText = derivative.String()
+    }
+    c.record(entry)
+    if err != nil {
+        return nil, err
+    }
+    return derivative, nil
+}
+
+// DerivativeAt evaluates the derivative of expr with respect to variable at the
+// values in vars, which must include variable. The evaluation applies the same
+// checks as Evaluate, so a NaN value or a division by zero is an error.
+func (c *Calculator) DerivativeAt(expr, variable string, vars map[string]float64) (float64, error) {
+    result, err := c.derivativeAt(expr, variable, vars)
+    c.record(HistoryEntry{Operator: "d/d" + variable, Expression: derivativeLabel(expr, variable), Result: result, Err: err})
+    if err != nil {
+        return 0, err
+    }
+    return result, nil
+}
+
+func (c *Calculator) derivativeAt(expr, variable string, vars map[string]float64) (float64, error) {
+    derivative, err := c.differentiate(expr, variable)
+    if err != nil {
+        return 0, err
+    }
+    if _, ok := vars[variable]; !ok {
+        return 0, fmt.Errorf("%w %q", ErrUnknownVariable, variable)
+    }
+    return c.eval(derivative, vars)
+}
+
+func (c *Calculator) differentiate(expr, variable string) (Expr, error) {
+    tree, err := Parse(expr)
+    if err != nil {
+        return nil, err
+    }
+    d := differentiator{variable: variable, mode: c.AngleMode(), registry: c.registry}
+    derivative, err := d.derive(tree)
+    if err != nil {
+        return nil, err
+    }
+    return Simplify(derivative), nil
+}
+
+func derivativeLabel(expr, variable string) string {
+    return "d/d" + variable + "(" + strings.TrimSpace(expr) + ")"
+}
+
+// differentiator applies the derivative rules; the result is not yet simplified
+type differentiator struct {
+    variable string
+    mode     AngleMode
+    registry *Registry // tells unknown functions from known ones without a rule; may be nil
+}
+
+func (d differentiator) derive(e Expr) (Expr, error) {
+    switch n := e.(type) {
+    case *NumberExpr:
+        return constant(0), nil
+    case *VariableExpr:
+        if n.Name == d.variable {
+            return constant(1), nil
+        }
+        return constant(0), nil
+    case *UnaryExpr:
+        du, err := d.derive(n.Operand)
+        if err != nil {
+            return nil, err
+        }
+        return &UnaryExpr{Op: n.Op, Operand: du}, nil
+    case *BinaryExpr:
+        return d.deriveBinary(n)
+    case *CallExpr:
+        return d.deriveCall(n)
+    }
+    return nil, fmt.Errorf("unsupported expression %T", e)
+}
+
+func (d differentiator) deriveBinary(n *BinaryExpr) (Expr, error) {
+    u, v := n.Left, n.Right
+    du, err := d.derive(u)
+    if err != nil {
+        return nil, err
+    }
+    dv, err := d.derive(v)
+    if err != nil {
+        return nil, err
+    }
+    switch n.Op {
+    case "+", "-":
+        return infix(n.Op, du, dv), nil
+    case "*":
+        // (uv)' = u'v + uv'
+        return infix("+", infix("*", du, v), infix("*", u, dv)), nil
+    case "/":
+        // (u/v)' = (u'v - uv') / v^2
+        return infix("/", infix("-", infix("*", du, v), infix("*", u, dv)), infix("^", v, constant(2))), nil
+    case "^":
+        switch {
+        case !d.dependsOn(v):
+            // (u^n)' = n u^(n-1) u'
+            return infix("*", infix("*", v, infix("^", u, infix("-", v, constant(1)))), du), nil
+        case !d.dependsOn(u):
+            // (a^v)' = a^v ln(a) v'
+            return infix("*", infix("*", n, callOf("ln", u)), dv), nil
+        }
+        // (u^v)' = u^v (v' ln(u) + v u' / u)
+        return infix("*", n, infix("+", infix("*", dv, callOf("ln", u)), infix("/", infix("*", v, du), u))), nil
+    }
+    return nil, fmt.Errorf("%w: %q", ErrNotDifferentiable, n.Op)
+}
+
+func (d differentiator) deriveCall(n *CallExpr) (Expr, error) {
+    rule, ok := derivativeRules[n.Name]
+    if !ok {
+        if d.registry != nil {
+            if _, known := d.registry.Lookup(n.Name); !known {
+                return nil, fmt.Errorf("%w %q", ErrUnknownOperator, n.Name)
+            }
+        }
+        return nil, fmt.Errorf("%w: %s", ErrNotDifferentiable, n.Name)
+    }
+    arity := 1
+    if n.Name == "root" {
+        arity = 2
+    }
+    if len(n.Args) != arity {
+        return nil, fmt.Errorf("%w: expected %d operands, got %d", ErrInvalidOperator, arity, len(n.Args))
+    }
+    for _, arg := range n.Args[1:] {
+        if d.dependsOn(arg) {
+            return nil, fmt.Errorf("%w: %s with a variable degree", ErrNotDifferentiable, n.Name)
+        }
+    }
+    u := n.Args[0]
+    du, err := d.derive(u)
+    if err != nil {
+        return nil, err
+    }
+    // chain rule: f(u)' = f'(u) u'
+    return infix("*", rule(n, u, d.mode), du), nil
+}
+
+// derivativeRules gives f'(u) for each function f(u, ...) in n
+var derivativeRules = map[string]func(n *CallExpr, u Expr, mode AngleMode) Expr{
+    // in degree mode sin(u) is sin(u·π/180), so its derivative gains a factor π/180
+    "sin": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return angleFactor(mode, callOf("cos", u))
+    },
+    "cos": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return angleFactor(mode, &UnaryExpr{Op: "-", Operand: callOf("sin", u)})
+    },
+    "tan": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return angleFactor(mode, infix("/", constant(1), infix("^", callOf("cos", u), constant(2))))
+    },
+    // inverse functions return degrees in degree mode, a factor of 180/π
+    "asin": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return inverseAngleFactor(mode, infix("/", constant(1), callOf("sqrt", infix("-", constant(1), infix("^", u, constant(2))))))
+    },
+    "acos": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return inverseAngleFactor(mode, infix("/", constant(-1), callOf("sqrt", infix("-", constant(1), infix("^", u, constant(2))))))
+    },
+    "atan": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return inverseAngleFactor(mode, infix("/", constant(1), infix("+", constant(1), infix("^", u, constant(2)))))
+    },
+    "ln": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return infix("/", constant(1), u)
+    },
+    "log": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return infix("/", constant(1), infix("*", u, callOf("ln", constant(10))))
+    },
+    "log2": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return infix("/", constant(1), infix("*", u, callOf("ln", constant(2))))
+    },
+    "exp": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return n
+    },
+    "sqrt": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return infix("/", constant(1), infix("*", constant(2), n))
+    },
+    // root(u, k) = u^(1/k), so its derivative is root(u, k) / (k u)
+    "root": func(n *CallExpr, u Expr, mode AngleMode) Expr {
+        return infix("/", n, infix("*", n.Args[1], u))
+    },
+}
+
+func angleFactor(mode AngleMode, e Expr) Expr {
+    if mode == Degrees {
+        return infix("*", constant(toRadians(mode, 1)), e)
+    }
+    return e
+}
+
+func inverseAngleFactor(mode AngleMode, e Expr) Expr {
+    if mode == Degrees {
+        return infix("*", constant(fromRadians(mode, 1)), e)
+    }
+    return e
+}
+
+// dependsOn reports whether e refers to the variable being differentiated
+func (d differentiator) dependsOn(e Expr) bool {
+    switch n := e.(type) {
+    case *VariableExpr:
+        return n.Name == d.variable
+    case *UnaryExpr:
+        return d.dependsOn(n.Operand)
+    case *BinaryExpr:
+        return d.dependsOn(n.Left) || d.dependsOn(n.Right)
+    case *CallExpr:
+        for _, arg := range n.Args {
+            if d.dependsOn(arg) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+func constant(v float64) Expr {
+    return &NumberExpr{Value: v}
+}
+
+func infix(op string, left, right Expr) Expr {
+    return &BinaryExpr{Op: op, Left: left, Right: right}
+}
+
+func callOf(name string, args ...Expr) Expr {
+    return &CallExpr{Name: name, Args: args}
+}
+
+// Simplify folds constant arithmetic and removes identities such as x + 0, 1 * x
+// and x ^ 1. Function calls are kept symbolic, so ln(10) stays as written, and
+// folds that would fail, like 1 / 0, are left for evaluation to report.
+func Simplify(e Expr) Expr {
+    switch n := e.(type) {
+    case *UnaryExpr:
+        return simplifyUnary(n.Op, Simplify(n.Operand))
+    case *BinaryExpr:
+        return simplifyBinary(n.Op, Simplify(n.Left), Simplify(n.Right))
+    case *CallExpr:
+        args := make([]Expr, len(n.Args))
+        for i, arg := range n.Args {
+            args[i] = Simplify(arg)
+        }
+        return &CallExpr{Name: n.Name, Args: args}
+    }
+    return e
+}
+
+func simplifyUnary(op string, operand Expr) Expr {
+    if op == "+" {
+        return operand
+    }
+    switch n := operand.(type) {
+    case *NumberExpr:
+        return constant(-n.Value)
+    case *UnaryExpr:
+        return n.Operand // -(-x) = x, as a "+" operand is already removed
+    }
+    return &UnaryExpr{Op: op, Operand: operand}
+}
+
+func simplifyBinary(op string, left, right Expr) Expr {
+    a, leftIsNumber := numberValue(left)
+    b, rightIsNumber := numberValue(right)
+    if leftIsNumber && rightIsNumber {
+        if v, ok := fold(op, a, b); ok {
+            return constant(v)
+        }
+        return infix(op, left, right)
+    }
+
+    switch op {
+    case "+":
+        switch {
+        case leftIsNumber && a == 0:
+            return right
+        case rightIsNumber && b == 0:
+            return left
+        case isNegation(right):
+            return simplifyBinary("-", left, right.(*UnaryExpr).Operand)
+        }
+    case "-":
+        switch {
+        case rightIsNumber && b == 0:
+            return left
+        case leftIsNumber && a == 0:
+            return simplifyUnary("-", right)
+        case isNegation(right):
+            return simplifyBinary("+", left, right.(*UnaryExpr).Operand)
+        }
+    case "*":
+        if rightIsNumber {
+            // keep constant factors on the left: x * 2 becomes 2 * x
+            left, right, a, b = right, left, b, a
+            leftIsNumber, rightIsNumber = true, false
+        }
+        switch {
+        case leftIsNumber && a == 0:
+            return constant(0)
+        case leftIsNumber && a == 1:
+            return right
+        case leftIsNumber && a == -1:
+            return simplifyUnary("-", right)
+        case isNegation(left):
+            return simplifyUnary("-", simplifyBinary("*", left.(*UnaryExpr).Operand, right))
+        case isNegation(right):
+            return simplifyUnary("-", simplifyBinary("*", left, right.(*UnaryExpr).Operand))
+        }
+        if inner, ok := right.(*BinaryExpr); ok && leftIsNumber && inner.Op == "*" {
+            // 2 * (3 * x) = 6 * x
+            if c, ok := numberValue(inner.Left); ok {
+                return simplifyBinary("*", constant(a*c), inner.Right)
+            }
+        }
+    case "/":
+        switch {
+        case rightIsNumber && b == 1:
+            return left
+        case leftIsNumber && a == 0:
+            return constant(0)
+        case isNegation(left):
+            return simplifyUnary("-", simplifyBinary("/", left.(*UnaryExpr).Operand, right))
+        }
+    case "^":
+        switch {
+        case rightIsNumber && b == 1:
+            return left
+        case rightIsNumber && b == 0:
+            return constant(1)
+        case leftIsNumber && a == 1:
+            return constant(1)
+        }
+    }
+    return infix(op, left, right)
+}
+
+func numberValue(e Expr) (float64, bool) {
+    n, ok := e.(*NumberExpr)
+    if !ok {
+        return 0, false
+    }
+    return n.Value, true
+}
+
+func isNegation(e Expr) bool {
+    n, ok := e.(*UnaryExpr)
+    return ok && n.Op == "-"
+}
+
+// fold computes a op b when the result is an ordinary finite number
+func fold(op string, a, b float64) (float64, bool) {
+    var v float64
+    switch op {
+    case "+":
+        v = a + b
+    case "-":
+        v = a - b
+    case "*":
+        v = a * b
+    case "/":
+        if b == 0 {
+            return 0, false
+        }
+        v = a / b
+    case "^":
+        if a < 0 && b != math.Trunc(b) {
+            return 0, false
+        }
+        v = math.Pow(a, b)
+    default:
+        return 0, false
+    }
+    return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+}
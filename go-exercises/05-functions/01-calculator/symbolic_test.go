@@ -0,0 +1,174 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+func TestDifferentiate(t *testing.T) {
+    tests := []struct {
+        expr     string
+        expected string
+        wantErr  error
+    }{
+        {"5", "0", nil},
+        {"x", "1", nil},
+        {"y", "0", nil},
+        {"3 * x + 2", "3", nil},
+        {"x ^ 2", "2 * x", nil},
+        {"x ^ 3 - 4 * x", "3 * x ^ 2 - 4", nil},
+        {"-x ^ 2", "-(2 * x)", nil},
+        {"1 / x", "-1 / x ^ 2", nil},
+        {"x * y", "y", nil},
+        {"sin(x)", "cos(x)", nil},
+        {"cos(2 * x)", "-(2 * sin(2 * x))", nil},
+        {"x * sin(x)", "sin(x) + x * cos(x)", nil},
+        {"exp(x ^ 2)", "exp(x ^ 2) * (2 * x)", nil},
+        {"ln(x)", "1 / x", nil},
+        {"log(x)", "1 / (x * ln(10))", nil},
+        {"sqrt(x)", "1 / (2 * sqrt(x))", nil},
+        {"2 ^ x", "2 ^ x * ln(2)", nil},
+        {"x ^ x", "x ^ x * (ln(x) + x / x)", nil},
+        {"root(x, 3)", "root(x, 3) / (3 * x)", nil},
+        {"floor(x)", "", ErrNotDifferentiable},
+        {"x % 2", "", ErrNotDifferentiable},
+        {"root(2, x)", "", ErrNotDifferentiable},
+        {"sin(x, 1)", "", ErrInvalidOperator},
+        {"x +", "", ErrSyntax},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            tree, err := Parse(tt.expr)
+            var derivative Expr
+            if err == nil {
+                derivative, err = Differentiate(tree, "x")
+            }
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := derivative.String(); got != tt.expected {
+                t.Errorf("d/dx(%s) = %q, want %q", tt.expr, got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestSimplify(t *testing.T) {
+    tests := []struct {
+        expr     string
+        expected string
+    }{
+        {"2 * 3 + 4", "10"},
+        {"x + 0", "x"},
+        {"0 + x", "x"},
+        {"x - 0", "x"},
+        {"0 - x", "-x"},
+        {"1 * x * 1", "x"},
+        {"0 * sin(x)", "0"},
+        {"x * 2", "2 * x"},
+        {"2 * (3 * x)", "6 * x"},
+        {"x / 1", "x"},
+        {"x ^ 1", "x"},
+        {"x ^ 0", "1"},
+        {"1 ^ x", "1"},
+        {"--x", "x"},
+        {"x + -y", "x - y"},
+        {"x - -y", "x + y"},
+        {"1 / 0 + x", "1 / 0 + x"},
+        {"(-8) ^ 0.5", "(-8) ^ 0.5"},
+        {"ln(2 + 8)", "ln(10)"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.expr, func(t *testing.T) {
+            tree, err := Parse(tt.expr)
+            if err != nil {
+                t.Fatalf("Parse failed: %v", err)
+            }
+            if got := Simplify(tree).String(); got != tt.expected {
+                t.Errorf("Simplify(%s) = %q, want %q", tt.expr, got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestCalculatorDerivative(t *testing.T) {
+    calc := NewCalculator()
+    tests := []struct {
+        name     string
+        expr     string
+        vars     map[string]float64
+        expected float64
+        wantErr  error
+    }{
+        {"polynomial", "x ^ 3 - 2 * x", map[string]float64{"x": 2}, 10, nil},
+        {"with another variable", "a * x ^ 2", map[string]float64{"x": 3, "a": 0.5}, 3, nil},
+        {"sine at zero", "sin(x)", map[string]float64{"x": 0}, 1, nil},
+        {"division by zero", "ln(x)", map[string]float64{"x": 0}, 0, ErrDivisionByZero},
+        {"domain", "sqrt(x)", map[string]float64{"x": -1}, 0, ErrDomain},
+        {"NaN value", "x ^ 2", map[string]float64{"x": math.NaN()}, 0, ErrNaN},
+        {"missing variable", "x ^ 2", map[string]float64{"y": 1}, 0, ErrUnknownVariable},
+        {"unknown function", "foo(x)", map[string]float64{"x": 1}, 0, ErrUnknownOperator},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := calc.DerivativeAt(tt.expr, "x", tt.vars)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(result-tt.expected) > 1e-12 {
+                t.Errorf("got %v, want %v", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestDerivativeDegrees(t *testing.T) {
+    calc := NewCalculator()
+    calc.SetAngleMode(Degrees)
+    slope, err := calc.DerivativeAt("sin(x)", "x", map[string]float64{"x": 60})
+    if err != nil || math.Abs(slope-math.Pi/360) > 1e-15 {
+        t.Errorf("d/dx sin(x) at 60° = %v, %v; want π/360", slope, err)
+    }
+    slope, err = calc.DerivativeAt("atan(x)", "x", map[string]float64{"x": 1})
+    if err != nil || math.Abs(slope-90/math.Pi) > 1e-12 {
+        t.Errorf("d/dx atan(x) at 1 in degrees = %v, %v; want 90/π", slope, err)
+    }
+}
+
+func TestDerivativeHistory(t *testing.T) {
+    calc := NewCalculator()
+    calc.Differentiate("x ^ 2", "x")
+    calc.DerivativeAt("x ^ 2", "x", map[string]float64{"x": 3})
+    calc.Differentiate("floor(t)", "t")
+
+    want := []string{
+        "d/dx(x ^ 2) = 2 * x",
+        "d/dx(x ^ 2) = 6.000000",
+        "d/dt(floor(t)) = error: invalid operation: not differentiable: floor",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
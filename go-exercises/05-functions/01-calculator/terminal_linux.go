@@ -0,0 +1,33 @@
+//go:build linux
+
+package main
+
+import (
+    "syscall"
+    "unsafe"
+)
+
+// makeRaw switches the terminal on fd to raw mode so the REPL sees every key press,
+// and returns a function that restores the previous mode. It fails when fd is not a terminal.
+func makeRaw(fd uintptr) (func(), error) {
+    var saved syscall.Termios
+    if err := ioctlTermios(fd, syscall.TCGETS, &saved); err != nil {
+        return nil, err
+    }
+    raw := saved
+    raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+    raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+    raw.Cc[syscall.VMIN] = 1
+    raw.Cc[syscall.VTIME] = 0
+    if err := ioctlTermios(fd, syscall.TCSETS, &raw); err != nil {
+        return nil, err
+    }
+    return func() { ioctlTermios(fd, syscall.TCSETS, &saved) }, nil
+}
+
+func ioctlTermios(fd, request uintptr, t *syscall.Termios) error {
+    if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(t))); errno != 0 {
+        return errno
+    }
+    return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// makeRaw is only implemented on Linux; elsewhere the REPL reads plain lines
+func makeRaw(fd uintptr) (func(), error) {
+    return nil, errors.New("raw terminal mode is not supported on this platform")
+}
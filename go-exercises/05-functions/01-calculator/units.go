@@ -0,0 +1,641 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// Errors returned by unit-aware operations
+var (
+    ErrIncompatibleUnits = errors.New("invalid operation: incompatible units")
+    ErrUnknownUnit       = errors.New("unknown unit")
+    ErrInvalidUnit       = errors.New("invalid unit")
+)
+
+// Base dimensions tracked by a Dimension: the seven SI base quantities plus information
+const (
+    DimLength = iota
+    DimMass
+    DimTime
+    DimCurrent
+    DimTemperature
+    DimAmount
+    DimLuminosity
+    DimInformation
+    dimensionCount
+)
+
+// maxUnitPower bounds the power of each base dimension so exponents stay far from int overflow
+const maxUnitPower = 64
+
+// baseSymbols names the unit of each base dimension
+var baseSymbols = [dimensionCount]string{"m", "kg", "s", "A", "K", "mol", "cd", "bit"}
+
+// Dimension holds the exponent of each base dimension, e.g. speed is length^1 time^-1
+type Dimension [dimensionCount]int
+
+// String writes the dimension in base units, e.g. "m s^-1"; it is empty for dimensionless values
+func (d Dimension) String() string {
+    var parts []string
+    for i, exp := range d {
+        switch exp {
+        case 0:
+        case 1:
+            parts = append(parts, baseSymbols[i])
+        default:
+            parts = append(parts, fmt.Sprintf("%s^%d", baseSymbols[i], exp))
+        }
+    }
+    return strings.Join(parts, " ")
+}
+
+func (d Dimension) times(other Dimension, sign int) Dimension {
+    for i := range d {
+        d[i] += sign * other[i]
+    }
+    return d
+}
+
+// Unit converts values to SI base units as value*Scale + Offset.
+// Only temperatures like °C and °F have an offset.
+type Unit struct {
+    Symbol string
+    Dim    Dimension
+    Scale  float64
+    Offset float64
+}
+
+// label is the unit's symbol, or its base-unit form for derived units
+func (u Unit) label() string {
+    if u.Symbol != "" {
+        return u.Symbol
+    }
+    if s := u.Dim.String(); s != "" {
+        return s
+    }
+    return "dimensionless"
+}
+
+// UnitRegistry holds units by symbol. It is safe for concurrent use.
+type UnitRegistry struct {
+    mu    sync.RWMutex
+    units map[string]Unit
+}
+
+// NewUnitRegistry creates a registry with the SI base units and common units of
+// length, mass, time, temperature and data size
+func NewUnitRegistry() *UnitRegistry {
+    r := &UnitRegistry{units: make(map[string]Unit)}
+    base := func(i int) Dimension {
+        var d Dimension
+        d[i] = 1
+        return d
+    }
+    length, mass, duration := base(DimLength), base(DimMass), base(DimTime)
+    temperature, data := base(DimTemperature), base(DimInformation)
+    defaults := []Unit{
+        {"m", length, 1, 0}, {"km", length, 1e3, 0}, {"cm", length, 1e-2, 0}, {"mm", length, 1e-3, 0},
+        {"µm", length, 1e-6, 0}, {"um", length, 1e-6, 0}, {"nm", length, 1e-9, 0},
+        {"inch", length, 0.0254, 0}, {"ft", length, 0.3048, 0}, {"yd", length, 0.9144, 0},
+        {"mi", length, 1609.344, 0}, {"nmi", length, 1852, 0},
+
+        {"kg", mass, 1, 0}, {"g", mass, 1e-3, 0}, {"mg", mass, 1e-6, 0}, {"t", mass, 1e3, 0},
+        {"lb", mass, 0.45359237, 0}, {"oz", mass, 0.45359237 / 16, 0},
+
+        {"s", duration, 1, 0}, {"ms", duration, 1e-3, 0}, {"us", duration, 1e-6, 0}, {"ns", duration, 1e-9, 0},
+        {"min", duration, 60, 0}, {"h", duration, 3600, 0}, {"day", duration, 86400, 0}, {"week", duration, 604800, 0},
+
+        {"K", temperature, 1, 0},
+        {"°C", temperature, 1, 273.15}, {"degC", temperature, 1, 273.15},
+        {"°F", temperature, 5.0 / 9, 273.15 - 32*5.0/9}, {"degF", temperature, 5.0 / 9, 273.15 - 32*5.0/9},
+
+        {"bit", data, 1, 0}, {"B", data, 8, 0},
+        {"kB", data, 8e3, 0}, {"MB", data, 8e6, 0}, {"GB", data, 8e9, 0}, {"TB", data, 8e12, 0},
+        {"KiB", data, 8 << 10, 0}, {"MiB", data, 8 << 20, 0}, {"GiB", data, 8 << 30, 0}, {"TiB", data, 8 << 40, 0},
+
+        {"A", base(DimCurrent), 1, 0}, {"mol", base(DimAmount), 1, 0}, {"cd", base(DimLuminosity), 1, 0},
+    }
+    for _, u := range defaults {
+        if err := r.Register(u); err != nil {
+            panic(err)
+        }
+    }
+    return r
+}
+
+// Register adds a unit; symbols must be unique names other than the keywords "in" and "to"
+func (r *UnitRegistry) Register(u Unit) error {
+    tokens, _ := tokenize(u.Symbol)
+    switch {
+    case len(tokens) != 2 || tokens[0].kind != tokenIdent || tokens[0].text != u.Symbol ||
+        u.Symbol == "in" || u.Symbol == "to":
+        return fmt.Errorf("%w: symbol %q is not a name", ErrInvalidUnit, u.Symbol)
+    case !(u.Scale > 0) || math.IsInf(u.Scale, 0) || math.IsNaN(u.Offset) || math.IsInf(u.Offset, 0):
+        return fmt.Errorf("%w %q: scale %v and offset %v", ErrInvalidUnit, u.Symbol, u.Scale, u.Offset)
+    }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, ok := r.units[u.Symbol]; ok {
+        return fmt.Errorf("%w %q: already registered", ErrInvalidUnit, u.Symbol)
+    }
+    r.units[u.Symbol] = u
+    return nil
+}
+
+// Lookup finds a unit by symbol
+func (r *UnitRegistry) Lookup(symbol string) (Unit, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    u, ok := r.units[symbol]
+    return u, ok
+}
+
+// Symbols returns every registered symbol in sorted order
+func (r *UnitRegistry) Symbols() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    symbols := make([]string, 0, len(r.units))
+    for symbol := range r.units {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+    return symbols
+}
+
+// Quantity is a value in a unit, e.g. 3 km
+type Quantity struct {
+    Value float64
+    Unit  Unit
+}
+
+// SI returns the value in SI base units
+func (q Quantity) SI() float64 {
+    return q.Value*q.Unit.Scale + q.Unit.Offset
+}
+
+func (q Quantity) String() string {
+    if q.Unit.Symbol == "" && q.Unit.Dim == (Dimension{}) {
+        return formatFloat(q.Value)
+    }
+    return formatFloat(q.Value) + " " + q.Unit.label()
+}
+
+// UnitCalculator performs unit-aware arithmetic and records it in the parent Calculator's history
+type UnitCalculator struct {
+    calc *Calculator
+}
+
+// Units returns a unit-aware view of the calculator; its units are shared by every view
+func (c *Calculator) Units() *UnitCalculator {
+    return &UnitCalculator{calc: c}
+}
+
+// Registry returns the calculator's unit registry for registering custom units
+func (uc *UnitCalculator) Registry() *UnitRegistry {
+    return uc.calc.units
+}
+
+// Quantity creates a quantity from a value and a unit such as "km", "m/s" or "kg m^2 s^-2"
+func (uc *UnitCalculator) Quantity(value float64, unit string) (Quantity, error) {
+    u, err := uc.ParseUnit(unit)
+    if err != nil {
+        return Quantity{}, err
+    }
+    return Quantity{Value: value, Unit: u}, nil
+}
+
+// ParseUnit reads a unit expression: registered symbols with optional integer powers,
+// joined by spaces, "*" or "/"
+func (uc *UnitCalculator) ParseUnit(unit string) (Unit, error) {
+    tokens, err := tokenize(unit)
+    if err != nil {
+        return Unit{}, err
+    }
+    p := &unitParser{parser: parser{tokens: tokens}, registry: uc.calc.units}
+    u, err := p.parseUnit()
+    if err != nil {
+        return Unit{}, err
+    }
+    if tok := p.peek(); tok.kind != tokenEOF {
+        return Unit{}, &ParseError{Column: tok.col, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+    }
+    return u, nil
+}
+
+// Add adds b to a in a's unit. For temperatures b counts as a difference, so 10 °C + 9 °F = 15 °C.
+func (uc *UnitCalculator) Add(a, b Quantity) (Quantity, error) {
+    return uc.apply("add", "+", []Quantity{a, b}, func() (Quantity, error) { return addQuantities(a, b, 1) })
+}
+
+// Subtract subtracts b from a in a's unit; like Add, b counts as a difference
+func (uc *UnitCalculator) Subtract(a, b Quantity) (Quantity, error) {
+    return uc.apply("subtract", "-", []Quantity{a, b}, func() (Quantity, error) { return addQuantities(a, b, -1) })
+}
+
+// Multiply multiplies two quantities; a plain number keeps the other operand's unit
+func (uc *UnitCalculator) Multiply(a, b Quantity) (Quantity, error) {
+    return uc.apply("multiply", "*", []Quantity{a, b}, func() (Quantity, error) { return multiplyQuantities(a, b, 1) })
+}
+
+// Divide divides a by b; quantities of the same dimension give a plain ratio
+func (uc *UnitCalculator) Divide(a, b Quantity) (Quantity, error) {
+    return uc.apply("divide", "/", []Quantity{a, b}, func() (Quantity, error) { return multiplyQuantities(a, b, -1) })
+}
+
+// Power raises q to an integer power, e.g. (3 m)^2 = 9 m^2; plain numbers accept any exponent
+func (uc *UnitCalculator) Power(q Quantity, n float64) (Quantity, error) {
+    return uc.apply("power", "^", []Quantity{q, {Value: n, Unit: Unit{Scale: 1}}}, func() (Quantity, error) { return powerQuantity(q, n) })
+}
+
+// Convert expresses q in another unit of the same dimension, e.g. Convert(q, "mi")
+func (uc *UnitCalculator) Convert(q Quantity, unit string) (Quantity, error) {
+    u, err := uc.ParseUnit(unit)
+    if err != nil {
+        return Quantity{}, err
+    }
+    return uc.apply("convert", "in", []Quantity{q, {Value: 1, Unit: u}}, func() (Quantity, error) {
+        return convertQuantity(q, u)
+    })
+}
+
+// Evaluate parses and evaluates input such as "3 km + 200 m in mi" and records it
+// as a single history entry. Without a trailing "in <unit>" (or "to <unit>") the
+// result keeps the unit of the leftmost operand.
+func (uc *UnitCalculator) Evaluate(input string) (Quantity, error) {
+    result, err := uc.evaluate(input)
+    entry := HistoryEntry{Operator: "units", Expression: strings.TrimSpace(input), Result: result.Value, Err: err}
+    if err == nil {
+        entry.ResultText = result.String()
+    }
+    uc.calc.record(entry)
+    if err != nil {
+        return Quantity{}, err
+    }
+    return result, nil
+}
+
+func (uc *UnitCalculator) evaluate(input string) (Quantity, error) {
+    tokens, err := tokenize(input)
+    if err != nil {
+        return Quantity{}, err
+    }
+    p := &unitParser{parser: parser{tokens: tokens}, registry: uc.calc.units}
+    result, err := p.parseQuantity()
+    if err != nil {
+        return Quantity{}, err
+    }
+    if tok := p.peek(); tok.kind == tokenIdent && (tok.text == "in" || tok.text == "to") {
+        p.next()
+        target, err := p.parseUnit()
+        if err != nil {
+            return Quantity{}, err
+        }
+        if result, err = convertQuantity(result, target); err != nil {
+            return Quantity{}, err
+        }
+    }
+    if tok := p.peek(); tok.kind != tokenEOF {
+        return Quantity{}, &ParseError{Column: tok.col, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+    }
+    return result, nil
+}
+
+// apply validates the operands, runs fn and records the operation with units
+func (uc *UnitCalculator) apply(name, symbol string, operands []Quantity, fn func() (Quantity, error)) (Quantity, error) {
+    entry := HistoryEntry{Operator: symbol}
+    for _, q := range operands {
+        entry.Operands = append(entry.Operands, q.Value)
+        entry.OperandText = append(entry.OperandText, q.String())
+    }
+    if name == "convert" {
+        // the target is a unit, not a quantity
+        entry.OperandText[1] = operands[1].Unit.label()
+        entry.Expression = entry.OperandText[0] + " in " + entry.OperandText[1]
+    }
+    result, err := fn()
+    if err != nil {
+        err = &CalcError{Op: name, Operands: entry.Operands, OperandText: entry.OperandText, Err: err}
+        entry.Err = err
+        uc.calc.record(entry)
+        return Quantity{}, err
+    }
+    entry.Result, entry.ResultText = result.Value, result.String()
+    uc.calc.record(entry)
+    return result, nil
+}
+
+// checkQuantities applies the NaN and infinity checks of the scalar operators
+func checkQuantities(qs ...Quantity) error {
+    for _, q := range qs {
+        if math.IsNaN(q.Value) {
+            return ErrNaN
+        }
+    }
+    for _, q := range qs {
+        if math.IsInf(q.Value, 0) {
+            return ErrInfinite
+        }
+    }
+    return nil
+}
+
+// checkResult reports an infinite result as an overflow
+func checkResult(q Quantity) (Quantity, error) {
+    if math.IsInf(q.Value, 0) || math.IsNaN(q.Value) {
+        return Quantity{}, ErrOverflow
+    }
+    return q, nil
+}
+
+func incompatible(a, b Unit) error {
+    return fmt.Errorf("%w: %s and %s", ErrIncompatibleUnits, a.label(), b.label())
+}
+
+// addQuantities returns a + sign*b in a's unit, treating b as an interval
+func addQuantities(a, b Quantity, sign float64) (Quantity, error) {
+    if err := checkQuantities(a, b); err != nil {
+        return Quantity{}, err
+    }
+    if a.Unit.Dim != b.Unit.Dim {
+        return Quantity{}, incompatible(a.Unit, b.Unit)
+    }
+    return checkResult(Quantity{Value: a.Value + sign*b.Value*b.Unit.Scale/a.Unit.Scale, Unit: a.Unit})
+}
+
+// multiplyQuantities returns a*b for sign 1 and a/b for sign -1
+func multiplyQuantities(a, b Quantity, sign int) (Quantity, error) {
+    if err := checkQuantities(a, b); err != nil {
+        return Quantity{}, err
+    }
+    if sign < 0 && b.Value == 0 {
+        return Quantity{}, ErrDivisionByZero
+    }
+    combine := func(x, y float64) float64 {
+        if sign < 0 {
+            return x / y
+        }
+        return x * y
+    }
+    dimensionless := Dimension{}
+    switch {
+    case b.Unit.Dim == dimensionless && b.Unit.Scale == 1:
+        return checkResult(Quantity{Value: combine(a.Value, b.Value), Unit: a.Unit})
+    case a.Unit.Dim == dimensionless && a.Unit.Scale == 1 && sign > 0:
+        return checkResult(Quantity{Value: a.Value * b.Value, Unit: b.Unit})
+    }
+    dim := a.Unit.Dim.times(b.Unit.Dim, sign)
+    return checkResult(Quantity{Value: combine(a.SI(), b.SI()), Unit: Unit{Dim: dim, Scale: 1}})
+}
+
+// powerQuantity raises q to an integer power; plain numbers accept any exponent
+func powerQuantity(q Quantity, n float64) (Quantity, error) {
+    if err := checkQuantities(q, Quantity{Value: n}); err != nil {
+        return Quantity{}, err
+    }
+    if q.Unit.Dim == (Dimension{}) && q.Unit.Scale == 1 {
+        value, err := power(q.Value, n)
+        if err != nil {
+            return Quantity{}, err
+        }
+        return checkResult(Quantity{Value: value, Unit: q.Unit})
+    }
+    if n != math.Trunc(n) || math.Abs(n) > maxUnitPower {
+        return Quantity{}, fmt.Errorf("%w: %s raised to %v", ErrIncompatibleUnits, q.Unit.label(), n)
+    }
+    var dim Dimension
+    for i, exp := range q.Unit.Dim {
+        dim[i] = exp * int(n)
+        if dim[i] > maxUnitPower || dim[i] < -maxUnitPower {
+            return Quantity{}, fmt.Errorf("%w: %s raised to %v exceeds power %d", ErrIncompatibleUnits, q.Unit.label(), n, maxUnitPower)
+        }
+    }
+    return checkResult(Quantity{Value: math.Pow(q.SI(), n), Unit: Unit{Dim: dim, Scale: 1}})
+}
+
+// convertQuantity expresses q in unit u, applying offsets for temperatures
+func convertQuantity(q Quantity, u Unit) (Quantity, error) {
+    if err := checkQuantities(q); err != nil {
+        return Quantity{}, err
+    }
+    if q.Unit.Dim != u.Dim {
+        return Quantity{}, incompatible(q.Unit, u)
+    }
+    value := (q.SI() - u.Offset) / u.Scale
+    if q.Unit.Offset != 0 || u.Offset != 0 {
+        // offsets cancel only approximately in binary, so -40 °C would be -40.00000000000006 °F;
+        // twelve significant digits is still far finer than any thermometer
+        value, _ = strconv.ParseFloat(strconv.FormatFloat(value, 'g', 12, 64), 64)
+    }
+    return checkResult(Quantity{Value: value, Unit: u})
+}
+
+// unitParser extends the expression parser with quantities and unit expressions:
+//
+//  quantity := term (("+" | "-") term)*
+//  term     := unary (("*" | "/") unary)*
+//  unary    := "-" unary | power
+//  power    := primary ("^" unary)?
+//  primary  := number unit? | unit | "(" quantity ")"
+//  unit     := symbol ("^" "-"? number)? (("*" | "/")? symbol ("^" "-"? number)?)*
+type unitParser struct {
+    parser
+    registry *UnitRegistry
+}
+
+func (p *unitParser) parseQuantity() (Quantity, error) {
+    left, err := p.parseTerm()
+    if err != nil {
+        return Quantity{}, err
+    }
+    for p.peek().kind == tokenOperator && (p.peek().text == "+" || p.peek().text == "-") {
+        sign := 1.0
+        if p.next().text == "-" {
+            sign = -1
+        }
+        right, err := p.parseTerm()
+        if err != nil {
+            return Quantity{}, err
+        }
+        if left, err = addQuantities(left, right, sign); err != nil {
+            return Quantity{}, err
+        }
+    }
+    return left, nil
+}
+
+func (p *unitParser) parseTerm() (Quantity, error) {
+    left, err := p.parseUnary()
+    if err != nil {
+        return Quantity{}, err
+    }
+    for p.peek().kind == tokenOperator && (p.peek().text == "*" || p.peek().text == "/") {
+        sign := 1
+        if p.next().text == "/" {
+            sign = -1
+        }
+        right, err := p.parseUnary()
+        if err != nil {
+            return Quantity{}, err
+        }
+        if left, err = multiplyQuantities(left, right, sign); err != nil {
+            return Quantity{}, err
+        }
+    }
+    return left, nil
+}
+
+func (p *unitParser) parseUnary() (Quantity, error) {
+    if tok := p.peek(); tok.kind == tokenOperator && tok.text == "-" {
+        p.next()
+        q, err := p.parseUnary()
+        q.Value = -q.Value
+        return q, err
+    }
+    base, err := p.parsePrimary()
+    if err != nil {
+        return Quantity{}, err
+    }
+    if tok := p.peek(); tok.kind == tokenOperator && tok.text == "^" {
+        p.next()
+        exponent, err := p.parseUnary()
+        if err != nil {
+            return Quantity{}, err
+        }
+        if exponent.Unit.Dim != (Dimension{}) {
+            return Quantity{}, fmt.Errorf("%w: exponent %s has a unit", ErrIncompatibleUnits, exponent)
+        }
+        return powerQuantity(base, exponent.SI())
+    }
+    return base, nil
+}
+
+func (p *unitParser) parsePrimary() (Quantity, error) {
+    tok := p.peek()
+    switch {
+    case tok.kind == tokenNumber:
+        p.next()
+        q := Quantity{Value: tok.value, Unit: Unit{Scale: 1}}
+        if p.atUnit() {
+            u, err := p.parseUnit()
+            if err != nil {
+                return Quantity{}, err
+            }
+            q.Unit = u
+        }
+        return q, nil
+    case p.atUnit():
+        u, err := p.parseUnit()
+        return Quantity{Value: 1, Unit: u}, err
+    case tok.kind == tokenLParen:
+        p.next()
+        q, err := p.parseQuantity()
+        if err != nil {
+            return Quantity{}, err
+        }
+        if closing := p.next(); closing.kind != tokenRParen {
+            return Quantity{}, &ParseError{Column: closing.col, Msg: fmt.Sprintf("expected \")\", got %q", closing.text)}
+        }
+        return q, nil
+    }
+    return Quantity{}, &ParseError{Column: tok.col, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+}
+
+// atUnit reports whether the next token starts a unit rather than a keyword
+func (p *unitParser) atUnit() bool {
+    tok := p.peek()
+    return tok.kind == tokenIdent && tok.text != "in" && tok.text != "to"
+}
+
+// parseUnit reads a unit expression. "*" and "/" belong to the unit only when a
+// symbol follows, so "6 m / 2 s" divides 6 m by 2 s.
+func (p *unitParser) parseUnit() (Unit, error) {
+    result := Unit{Scale: 1}
+    sign := 1
+    for first := true; ; first = false {
+        tok := p.peek()
+        if tok.kind != tokenIdent || tok.text == "in" || tok.text == "to" {
+            if first {
+                return Unit{}, &ParseError{Column: tok.col, Msg: fmt.Sprintf("expected a unit, got %q", tok.text)}
+            }
+            return result, nil
+        }
+        p.next()
+        u, ok := p.registry.Lookup(tok.text)
+        if !ok {
+            return Unit{}, fmt.Errorf("%w %q", ErrUnknownUnit, tok.text)
+        }
+        exp, err := p.parseUnitExponent()
+        if err != nil {
+            return Unit{}, err
+        }
+        if first && exp == 1 && sign == 1 && !p.unitContinues() {
+            return u, nil // a single registered unit keeps its symbol and offset
+        }
+        if u.Offset != 0 {
+            return Unit{}, fmt.Errorf("%w: %s cannot be part of a compound unit", ErrIncompatibleUnits, u.Symbol)
+        }
+        switch {
+        case first:
+        case sign < 0:
+            result.Symbol += "/"
+        default:
+            result.Symbol += "*"
+        }
+        result.Symbol += u.Symbol
+        if exp != 1 {
+            result.Symbol += fmt.Sprintf("^%d", exp)
+        }
+        result.Scale *= math.Pow(u.Scale, float64(sign*exp))
+        for i := range result.Dim {
+            result.Dim[i] += sign * exp * u.Dim[i]
+        }
+        sign = 1
+        if p.unitContinues() {
+            if op := p.peek(); op.kind == tokenOperator {
+                p.next()
+                if op.text == "/" {
+                    sign = -1
+                }
+            }
+        }
+    }
+}
+
+// unitContinues reports whether a unit expression goes on: another symbol, or "*" or "/" followed by one
+func (p *unitParser) unitContinues() bool {
+    if p.atUnit() {
+        return true
+    }
+    tok := p.peek()
+    if tok.kind != tokenOperator || (tok.text != "*" && tok.text != "/") || p.pos+1 >= len(p.tokens) {
+        return false
+    }
+    next := p.tokens[p.pos+1]
+    return next.kind == tokenIdent && next.text != "in" && next.text != "to"
+}
+
+// parseUnitExponent reads an optional "^n" or "^-n" after a unit symbol
+func (p *unitParser) parseUnitExponent() (int, error) {
+    if tok := p.peek(); tok.kind != tokenOperator || tok.text != "^" {
+        return 1, nil
+    }
+    p.next()
+    sign := 1
+    if tok := p.peek(); tok.kind == tokenOperator && tok.text == "-" {
+        p.next()
+        sign = -1
+    }
+    tok := p.next()
+    if tok.kind != tokenNumber || tok.value != math.Trunc(tok.value) {
+        return 0, &ParseError{Column: tok.col, Msg: fmt.Sprintf("expected an integer unit power, got %q", tok.text)}
+    }
+    if tok.value > maxUnitPower {
+        return 0, &ParseError{Column: tok.col, Msg: fmt.Sprintf("unit power %s exceeds %d", tok.text, maxUnitPower)}
+    }
+    return sign * int(tok.value), nil
+}
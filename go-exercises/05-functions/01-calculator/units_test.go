@@ -0,0 +1,242 @@
+package main
+
+import (
+    "errors"
+    "math"
+    "testing"
+)
+
+func mustQuantity(t *testing.T, uc *UnitCalculator, value float64, unit string) Quantity {
+    t.Helper()
+    q, err := uc.Quantity(value, unit)
+    if err != nil {
+        t.Fatalf("Quantity(%v, %q) failed: %v", value, unit, err)
+    }
+    return q
+}
+
+func TestUnitConvert(t *testing.T) {
+    uc := NewCalculator().Units()
+    tests := []struct {
+        name     string
+        value    float64
+        from, to string
+        expected float64
+        wantErr  error
+    }{
+        {"km to m", 3.2, "km", "m", 3200, nil},
+        {"mile to km", 1, "mi", "km", 1.609344, nil},
+        {"feet to inches", 2, "ft", "inch", 24, nil},
+        {"pounds to grams", 1, "lb", "g", 453.59237, nil},
+        {"hours to seconds", 1.5, "h", "s", 5400, nil},
+        {"boiling water in °F", 100, "°C", "°F", 212, nil},
+        {"body temperature in °C", 98.6, "degF", "degC", 37, nil},
+        {"absolute zero", 0, "K", "°C", -273.15, nil},
+        {"gibibyte to bytes", 1, "GiB", "B", 1 << 30, nil},
+        {"megabyte to bits", 1, "MB", "bit", 8e6, nil},
+        {"speed", 36, "km/h", "m/s", 10, nil},
+        {"compound with powers", 1, "kg m^2 s^-2", "kg*m^2/s^2", 1, nil},
+        {"length to mass", 1, "m", "kg", 0, ErrIncompatibleUnits},
+        {"data to time", 1, "B", "s", 0, ErrIncompatibleUnits},
+        {"unknown unit", 1, "parsec", "m", 0, ErrUnknownUnit},
+        {"offset unit in compound", 1, "°C/s", "K/s", 0, ErrIncompatibleUnits},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            q, err := uc.Quantity(tt.value, tt.from)
+            if err == nil {
+                q, err = uc.Convert(q, tt.to)
+            }
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if math.Abs(q.Value-tt.expected) > 1e-9*math.Max(1, math.Abs(tt.expected)) {
+                t.Errorf("got %v, want %v %s", q, tt.expected, tt.to)
+            }
+        })
+    }
+}
+
+func TestUnitArithmetic(t *testing.T) {
+    uc := NewCalculator().Units()
+    km := mustQuantity(t, uc, 3, "km")
+    m := mustQuantity(t, uc, 200, "m")
+    kg := mustQuantity(t, uc, 2, "kg")
+    celsius := mustQuantity(t, uc, 10, "°C")
+    fahrenheit := mustQuantity(t, uc, 9, "°F")
+    seconds := mustQuantity(t, uc, 4, "s")
+    number := Quantity{Value: 2, Unit: Unit{Scale: 1}}
+
+    tests := []struct {
+        name     string
+        op       func(a, b Quantity) (Quantity, error)
+        a, b     Quantity
+        expected string
+        wantErr  error
+    }{
+        {"add keeps the left unit", uc.Add, km, m, "3.2 km", nil},
+        {"subtract", uc.Subtract, m, km, "-2800 m", nil},
+        {"temperature difference", uc.Add, celsius, fahrenheit, "15 °C", nil},
+        {"add incompatible", uc.Add, km, kg, "", ErrIncompatibleUnits},
+        {"subtract incompatible", uc.Subtract, seconds, m, "", ErrIncompatibleUnits},
+        {"scale by a number", uc.Multiply, number, km, "6 km", nil},
+        {"divide by a number", uc.Divide, km, number, "1.5 km", nil},
+        {"area", uc.Multiply, km, m, "600000 m^2", nil},
+        {"speed", uc.Divide, m, seconds, "50 m s^-1", nil},
+        {"same dimension ratio", uc.Divide, km, m, "15", nil},
+        {"division by zero", uc.Divide, km, Quantity{Unit: m.Unit}, "", ErrDivisionByZero},
+        {"NaN value", uc.Add, Quantity{Value: math.NaN(), Unit: m.Unit}, m, "", ErrNaN},
+        {"infinite value", uc.Multiply, Quantity{Value: math.Inf(1), Unit: m.Unit}, m, "", ErrInfinite},
+        {"overflow", uc.Multiply, Quantity{Value: 1e200, Unit: km.Unit}, Quantity{Value: 1e200, Unit: km.Unit}, "", ErrOverflow},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := tt.op(tt.a, tt.b)
+            if tt.wantErr != nil {
+                var calcErr *CalcError
+                if !errors.Is(err, tt.wantErr) || !errors.As(err, &calcErr) {
+                    t.Errorf("expected *CalcError wrapping %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := result.String(); got != tt.expected {
+                t.Errorf("got %q, want %q", got, tt.expected)
+            }
+        })
+    }
+
+    if area, err := uc.Power(m, 2); err != nil || area.String() != "40000 m^2" {
+        t.Errorf("Power(200 m, 2) = %v, %v", area, err)
+    }
+    if _, err := uc.Power(m, 0.5); !errors.Is(err, ErrIncompatibleUnits) {
+        t.Errorf("expected ErrIncompatibleUnits for a fractional power of a unit, got %v", err)
+    }
+    for _, n := range []float64{65, -1e19, 1e300} {
+        if _, err := uc.Power(m, n); !errors.Is(err, ErrIncompatibleUnits) {
+            t.Errorf("Power(200 m, %v): expected ErrIncompatibleUnits, got %v", n, err)
+        }
+    }
+    if area, err := uc.Power(m, 2); err == nil {
+        if _, err := uc.Power(area, 40); !errors.Is(err, ErrIncompatibleUnits) {
+            t.Errorf("expected ErrIncompatibleUnits for m^80, got %v", err)
+        }
+    }
+}
+
+func TestUnitEvaluate(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected string
+        wantErr  error
+    }{
+        {"3 km + 200 m", "3.2 km", nil},
+        {"3 km + 200 m in m", "3200 m", nil},
+        {"1 mi + 1 km to km", "2.609344 km", nil},
+        {"2 * 3 ft + 6 inch in inch", "78 inch", nil},
+        {"6 m / 2 s", "3 m s^-1", nil},
+        {"100 m / 10 s in km/h", "36 km/h", nil},
+        {"(2 m)^2", "4 m^2", nil},
+        {"1 GiB / 1 MiB", "1024", nil},
+        {"-40 °C in °F", "-40 °F", nil},
+        {"0 degC to K", "273.15 K", nil},
+        {"90 min in h", "1.5 h", nil},
+        {"2 + 3", "5", nil},
+        {"3 km + 2 kg", "", ErrIncompatibleUnits},
+        {"3 km in s", "", ErrIncompatibleUnits},
+        {"3 furlong", "", ErrUnknownUnit},
+        {"3 km +", "", ErrSyntax},
+        {"3 km in", "", ErrSyntax},
+        {"(3 km", "", ErrSyntax},
+        {"5 m / 0 s", "", ErrDivisionByZero},
+        {"1 m^1e19", "", ErrSyntax},
+        {"1 m^-65", "", ErrSyntax},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.input, func(t *testing.T) {
+            uc := NewCalculator().Units()
+            result, err := uc.Evaluate(tt.input)
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if got := result.String(); got != tt.expected {
+                t.Errorf("got %q, want %q", got, tt.expected)
+            }
+        })
+    }
+}
+
+func TestUnitRegistry(t *testing.T) {
+    calc := NewCalculator()
+    uc := calc.Units()
+    furlong := Unit{Symbol: "furlong", Dim: Dimension{DimLength: 1}, Scale: 201.168}
+    if err := uc.Registry().Register(furlong); err != nil {
+        t.Fatalf("Register failed: %v", err)
+    }
+    if q, err := uc.Evaluate("8 furlong in mi"); err != nil || q.String() != "1 mi" {
+        t.Errorf("8 furlong in mi = %v, %v", q, err)
+    }
+    if u, ok := calc.Units().Registry().Lookup("furlong"); !ok || u != furlong {
+        t.Errorf("custom unit not shared between views: %v, %v", u, ok)
+    }
+
+    for _, u := range []Unit{
+        {Symbol: "m", Scale: 1},
+        {Symbol: "in", Scale: 1},
+        {Symbol: "2x", Scale: 1},
+        {Symbol: "m/s", Scale: 1},
+        {Symbol: "zero", Scale: 0},
+        {Symbol: "nan", Scale: math.NaN()},
+    } {
+        if err := uc.Registry().Register(u); !errors.Is(err, ErrInvalidUnit) {
+            t.Errorf("Register(%q): expected ErrInvalidUnit, got %v", u.Symbol, err)
+        }
+    }
+
+    if got := (Dimension{DimMass: 1, DimLength: 2, DimTime: -2}).String(); got != "m^2 kg s^-2" {
+        t.Errorf("Dimension.String() = %q", got)
+    }
+}
+
+func TestUnitHistory(t *testing.T) {
+    calc := NewCalculator()
+    uc := calc.Units()
+    km := mustQuantity(t, uc, 3, "km")
+    uc.Add(km, mustQuantity(t, uc, 2, "kg"))
+    uc.Convert(km, "mi")
+    uc.Evaluate("3 km + 200 m in m")
+    uc.Evaluate("1 m + 1 s")
+
+    want := []string{
+        "3 km + 2 kg = error: add(3 km, 2 kg): invalid operation: incompatible units: km and kg",
+        "3 km in mi = 1.8641135767120018 mi",
+        "3 km + 200 m in m = 3200 m",
+        "1 m + 1 s = error: invalid operation: incompatible units: m and s",
+    }
+    entries := calc.History()
+    if len(entries) != len(want) {
+        t.Fatalf("got %d history entries, want %d", len(entries), len(want))
+    }
+    for i, entry := range entries {
+        if got := entry.String(); got != want[i] {
+            t.Errorf("entry %d: got %q, want %q", i, got, want[i])
+        }
+    }
+}
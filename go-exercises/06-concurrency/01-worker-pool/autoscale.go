@@ -0,0 +1,171 @@
+package main
+
+import (
+    "math"
+    "sync"
+    "time"
+)
+
+// Autoscaler defaults
+const (
+    DefaultScaleInterval       = time.Second
+    DefaultScaleWindow         = 5
+    DefaultQueueDepthPerWorker = 1.0
+)
+
+// AutoscalerConfig controls how a pool grows and shrinks with its load
+type AutoscalerConfig struct {
+    MinWorkers          int                // lower bound, at least 1
+    MaxWorkers          int                // upper bound, at least MinWorkers
+    Interval            time.Duration      // time between samples
+    Window              int                // samples averaged for each decision
+    QueueDepthPerWorker float64            // grow when more tasks than this wait per worker
+    TargetLatency       time.Duration      // grow when tasks take longer from submission to result; zero ignores latency
+    OnScale             func(ScalingEvent) // called after every change of the worker count, in order
+}
+
+// ScalingEvent describes one decision of the autoscaler
+type ScalingEvent struct {
+    From, To   int
+    QueueDepth float64       // average queued tasks over the window
+    Latency    time.Duration // average task latency over the window
+    Reason     string
+}
+
+// WithAutoscaler adjusts the number of workers between config.MinWorkers and
+// config.MaxWorkers. Every Interval it samples the queue depth and the latency
+// of the tasks finished since the last sample; once Window samples are in, it
+// adds a worker if the pool is falling behind or removes one if it is idle.
+func WithAutoscaler(config AutoscalerConfig) PoolOption {
+    return func(c *poolConfig) {
+        c.autoscale = &config
+    }
+}
+
+// sample is the load seen during one interval
+type sample struct {
+    depth   int
+    latency time.Duration // total over count tasks
+    count   int
+}
+
+type autoscaler struct {
+    config  AutoscalerConfig
+    mu      sync.Mutex
+    pending sample // latencies since the last sample
+    samples []sample
+    emit    sync.Mutex // held while OnScale runs, so events arrive in order
+}
+
+func newAutoscaler(config AutoscalerConfig) *autoscaler {
+    config.MinWorkers = max(config.MinWorkers, 1)
+    config.MaxWorkers = max(config.MaxWorkers, config.MinWorkers)
+    if config.Interval <= 0 {
+        config.Interval = DefaultScaleInterval
+    }
+    if config.Window < 1 {
+        config.Window = DefaultScaleWindow
+    }
+    if config.QueueDepthPerWorker <= 0 {
+        config.QueueDepthPerWorker = DefaultQueueDepthPerWorker
+    }
+    return &autoscaler{config: config}
+}
+
+// queueSize is the default queue size for an autoscaled pool: room for one task
+// per worker beyond the queue depth that makes the autoscaler grow to MaxWorkers
+func (a *autoscaler) queueSize() int {
+    perWorker := math.Max(a.config.QueueDepthPerWorker+1, 2)
+    return int(math.Ceil(perWorker * float64(a.config.MaxWorkers)))
+}
+
+// clamp limits a worker count to the configured bounds
+func (a *autoscaler) clamp(n int) int {
+    return min(max(n, a.config.MinWorkers), a.config.MaxWorkers)
+}
+
+// observe records the latency of a finished task
+func (a *autoscaler) observe(latency time.Duration) {
+    a.mu.Lock()
+    a.pending.latency += latency
+    a.pending.count++
+    a.mu.Unlock()
+}
+
+// record adds a sample with the given queue depth. Once the window is full it
+// decides on the worker count for a pool of the given size, starts a new
+// window and reports whether the count changes.
+func (a *autoscaler) record(depth, workers int) (ScalingEvent, bool) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.pending.depth = depth
+    a.samples = append(a.samples, a.pending)
+    a.pending = sample{}
+    if len(a.samples) < a.config.Window {
+        return ScalingEvent{}, false
+    }
+
+    var total sample
+    for _, s := range a.samples {
+        total.depth += s.depth
+        total.latency += s.latency
+        total.count += s.count
+    }
+    a.samples = a.samples[:0]
+    event := ScalingEvent{From: workers, To: workers, QueueDepth: float64(total.depth) / float64(a.config.Window)}
+    if total.count > 0 {
+        event.Latency = total.latency / time.Duration(total.count)
+    }
+
+    target := a.config.TargetLatency
+    switch {
+    case event.QueueDepth > a.config.QueueDepthPerWorker*float64(workers):
+        event.To, event.Reason = workers+1, "queue depth"
+    case target > 0 && event.Latency > target:
+        event.To, event.Reason = workers+1, "latency"
+    case event.QueueDepth == 0 && (target == 0 || event.Latency <= target/2):
+        event.To, event.Reason = workers-1, "idle"
+    }
+    if event.To = a.clamp(event.To); event.To == workers {
+        event.Reason = ""
+    }
+    return event, event.To != workers
+}
+
+// autoscale samples the pool every interval until it stops
+func (p *Pool[In, Out]) autoscale() {
+    defer p.wg.Done()
+
+    for {
+        select {
+        case <-p.config.clock.After(p.scaler.config.Interval):
+            p.scale()
+        case <-p.ctx.Done():
+            return
+        }
+    }
+}
+
+// scale takes one sample and resizes the pool if the autoscaler decides to
+func (p *Pool[In, Out]) scale() {
+    p.mu.Lock()
+    if p.ctx.Err() != nil {
+        p.mu.Unlock()
+        return
+    }
+    event, changed := p.scaler.record(p.queue.len(), p.numWorkers)
+    if changed {
+        p.resize(event.To)
+    }
+    if !changed || p.scaler.config.OnScale == nil {
+        p.mu.Unlock()
+        return
+    }
+
+    // take the emit lock before releasing p.mu, so the next change cannot be
+    // reported first, and call OnScale without p.mu so it can use the pool
+    p.scaler.emit.Lock()
+    defer p.scaler.emit.Unlock()
+    p.mu.Unlock()
+    p.scaler.config.OnScale(event)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// stillClock never fires its timers, so tests drive the autoscaler by hand
+type stillClock struct {
+    fakeClock
+}
+
+func (c *stillClock) After(time.Duration) <-chan time.Time {
+    return nil
+}
+
+// gate is a task function that blocks until released and tracks how many
+// calls run at once
+type gate struct {
+    release chan struct{}
+    running atomic.Int32
+    peak    atomic.Int32
+}
+
+func newGate() *gate {
+    return &gate{release: make(chan struct{})}
+}
+
+func (g *gate) run(context.Context, int) (int, error) {
+    n := g.running.Add(1)
+    for {
+        old := g.peak.Load()
+        if n <= old || g.peak.CompareAndSwap(old, n) {
+            break
+        }
+    }
+    <-g.release
+    g.running.Add(-1)
+    return 0, nil
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+    t.Helper()
+    deadline := time.Now().Add(time.Second)
+    for !cond() {
+        if time.Now().After(deadline) {
+            t.Fatalf("timed out waiting for %s", what)
+        }
+        time.Sleep(time.Millisecond)
+    }
+}
+
+func TestResize(t *testing.T) {
+    g := newGate()
+    pool := NewPool(1, g.run, WithQueueSize(10))
+    if err := pool.Resize(2); err != nil || pool.Workers() != 2 {
+        t.Fatalf("Resize before Start = %v, %d workers", err, pool.Workers())
+    }
+    pool.Start()
+
+    var futures []*Future[int]
+    for i := 0; i < 4; i++ {
+        future, _ := pool.Submit(i)
+        futures = append(futures, future)
+    }
+    waitFor(t, "2 running tasks", func() bool { return g.running.Load() == 2 })
+
+    if err := pool.Resize(4); err != nil {
+        t.Fatalf("Resize(4) failed: %v", err)
+    }
+    waitFor(t, "4 running tasks", func() bool { return g.running.Load() == 4 })
+
+    // shrinking lets the in-flight tasks finish
+    if err := pool.Resize(1); err != nil || pool.Workers() != 1 {
+        t.Fatalf("Resize(1) = %v, %d workers", err, pool.Workers())
+    }
+    close(g.release)
+    for i, future := range futures {
+        if result, err := future.Wait(context.Background()); err != nil || result.Error != nil {
+            t.Errorf("task %d dropped: %+v, %v", i, result, err)
+        }
+    }
+
+    // only one worker is left to run new tasks
+    g.peak.Store(0)
+    var wg sync.WaitGroup
+    for i := 0; i < 5; i++ {
+        future, _ := pool.Submit(i)
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            future.Wait(context.Background())
+        }()
+    }
+    wg.Wait()
+    if n := g.peak.Load(); n != 1 {
+        t.Errorf("peak concurrency %d after shrinking to 1", n)
+    }
+
+    if err := pool.Resize(0); !errors.Is(err, ErrInvalidSize) {
+        t.Errorf("Resize(0): expected ErrInvalidSize, got %v", err)
+    }
+    pool.Stop()
+    if err := pool.Resize(2); !errors.Is(err, ErrPoolStopped) {
+        t.Errorf("Resize after Stop: expected ErrPoolStopped, got %v", err)
+    }
+}
+
+func TestAutoscalerDecisions(t *testing.T) {
+    config := AutoscalerConfig{MinWorkers: 1, MaxWorkers: 4, Window: 2, TargetLatency: 100 * time.Millisecond}
+    tests := []struct {
+        name     string
+        depths   []int
+        latency  time.Duration // of one task finished in each interval, if non-zero
+        workers  int
+        expected int
+        reason   string
+    }{
+        {"deep queue", []int{5, 5}, 0, 2, 3, "queue depth"},
+        {"slow tasks", []int{1, 1}, 200 * time.Millisecond, 2, 3, "latency"},
+        {"idle", []int{0, 0}, 0, 2, 1, "idle"},
+        {"keeping up", []int{1, 1}, 80 * time.Millisecond, 2, 2, ""},
+        {"empty queue but slowish tasks", []int{0, 0}, 80 * time.Millisecond, 2, 2, ""},
+        {"at the maximum", []int{10, 10}, 0, 4, 4, ""},
+        {"at the minimum", []int{0, 0}, 0, 1, 1, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            scaler := newAutoscaler(config)
+            var event ScalingEvent
+            var changed bool
+            for i, depth := range tt.depths {
+                if tt.latency > 0 {
+                    scaler.observe(tt.latency)
+                }
+                event, changed = scaler.record(depth, tt.workers)
+                if i < len(tt.depths)-1 && changed {
+                    t.Fatal("decided before the window was full")
+                }
+            }
+            if changed != (tt.expected != tt.workers) || event.To != tt.expected || event.Reason != tt.reason {
+                t.Errorf("got %+v, changed %v; want %d workers for %q", event, changed, tt.expected, tt.reason)
+            }
+            if event.From != tt.workers || event.Latency != tt.latency {
+                t.Errorf("unexpected event %+v", event)
+            }
+        })
+    }
+}
+
+func TestAutoscalerResizesPool(t *testing.T) {
+    g := newGate()
+    var mu sync.Mutex
+    var events []ScalingEvent
+    pool := NewPool(5, g.run, WithQueueSize(10), WithClock(&stillClock{}), WithAutoscaler(AutoscalerConfig{
+        MinWorkers: 1,
+        MaxWorkers: 2,
+        Window:     1,
+        OnScale: func(e ScalingEvent) {
+            mu.Lock()
+            events = append(events, e)
+            mu.Unlock()
+        },
+    }))
+    if n := pool.Workers(); n != 2 {
+        t.Fatalf("initial size not clamped: %d workers", n)
+    }
+    pool.Start()
+    defer pool.Stop()
+    pool.Resize(1)
+
+    var futures []*Future[int]
+    for i := 0; i < 4; i++ {
+        future, _ := pool.Submit(i)
+        futures = append(futures, future)
+    }
+    waitFor(t, "a running task", func() bool { return g.running.Load() == 1 })
+
+    pool.scale() // 3 queued for 1 worker
+    if n := pool.Workers(); n != 2 {
+        t.Fatalf("expected the pool to grow to 2 workers, got %d", n)
+    }
+    pool.scale() // already at the maximum
+    close(g.release)
+    for _, future := range futures {
+        future.Wait(context.Background())
+    }
+    pool.scale() // nothing queued
+    if n := pool.Workers(); n != 1 {
+        t.Errorf("expected the idle pool to shrink to 1 worker, got %d", n)
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    if len(events) != 2 || events[0].From != 1 || events[0].To != 2 || events[0].Reason != "queue depth" ||
+        events[1].From != 2 || events[1].To != 1 || events[1].Reason != "idle" {
+        t.Errorf("unexpected scaling events %+v", events)
+    }
+}
+
+func TestAutoscalerQueueSize(t *testing.T) {
+    tests := []struct {
+        name     string
+        opts     []PoolOption
+        expected int
+    }{
+        {"two per worker", nil, 6},
+        {"explicit size", []PoolOption{WithQueueSize(5)}, 5},
+        {"at least one", []PoolOption{WithQueueSize(0)}, 1},
+        {"room to reach MaxWorkers", []PoolOption{WithAutoscaler(AutoscalerConfig{MaxWorkers: 8})}, 16},
+        {"deeper queue per worker", []PoolOption{WithAutoscaler(AutoscalerConfig{MaxWorkers: 8, QueueDepthPerWorker: 2.5})}, 28},
+        {"explicit size with autoscaler", []PoolOption{WithQueueSize(5), WithAutoscaler(AutoscalerConfig{MaxWorkers: 8})}, 5},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if size := NewPool(3, process, tt.opts...).queue.size; size != tt.expected {
+                t.Errorf("queue size %d, want %d", size, tt.expected)
+            }
+        })
+    }
+}
+
+func TestAutoscalerReachesMaxWorkers(t *testing.T) {
+    const maxWorkers = 6
+    g := newGate()
+    var mu sync.Mutex
+    var events []ScalingEvent
+    pool := NewPool(1, g.run, WithClock(&stillClock{}), WithAutoscaler(AutoscalerConfig{
+        MaxWorkers: maxWorkers,
+        Window:     1,
+        OnScale: func(e ScalingEvent) {
+            mu.Lock()
+            events = append(events, e)
+            mu.Unlock()
+        },
+    }))
+    pool.Start()
+    defer pool.Stop()
+    defer close(g.release)
+
+    // one running task and a full default queue, far more than the initial worker's share
+    for i := 0; i <= pool.queue.size; i++ {
+        if _, err := pool.Submit(i); err != nil {
+            t.Fatalf("Submit failed: %v", err)
+        }
+        if i == 0 {
+            waitFor(t, "a running task", func() bool { return g.running.Load() == 1 })
+        }
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < maxWorkers; j++ {
+                pool.scale()
+            }
+        }()
+    }
+    wg.Wait()
+
+    if n := pool.Workers(); n != maxWorkers {
+        t.Errorf("expected the queue depth to grow the pool to %d workers, got %d", maxWorkers, n)
+    }
+    mu.Lock()
+    defer mu.Unlock()
+    if len(events) != maxWorkers-1 {
+        t.Fatalf("got %d scaling events, want %d: %+v", len(events), maxWorkers-1, events)
+    }
+    for i, e := range events {
+        if e.From != i+1 || e.To != i+2 {
+            t.Errorf("event %d out of order: %+v", i, e)
+        }
+    }
+}
+
+func TestAutoscalerLoop(t *testing.T) {
+    g := newGate()
+    defer close(g.release)
+    pool := NewWorkerPool(1, WithQueueSize(10), WithAutoscaler(AutoscalerConfig{
+        MinWorkers: 1,
+        MaxWorkers: 3,
+        Interval:   5 * time.Millisecond,
+        Window:     1,
+    }))
+    pool.Start()
+    defer pool.Stop()
+
+    for i := 0; i < 6; i++ {
+        pool.Submit(taskFunc(func() Result {
+            g.run(context.Background(), 0)
+            return Result{}
+        }))
+    }
+    waitFor(t, "the autoscaler to reach 3 workers", func() bool { return pool.Workers() == 3 })
+    waitFor(t, "3 running tasks", func() bool { return g.running.Load() == 3 })
+}
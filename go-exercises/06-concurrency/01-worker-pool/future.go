@@ -0,0 +1,178 @@
+package main
+
+import (
+    "container/list"
+    "context"
+    "sync"
+)
+
+// Future is a handle to the result of one submitted task
+type Future[Out any] struct {
+    id      uint64
+    done    chan struct{}
+    stopped <-chan struct{}
+    result  TypedResult[Out]
+    claim   func(id uint64)
+}
+
+// ID returns the task ID, which is also set on the task's result
+func (f *Future[Out]) ID() uint64 {
+    return f.id
+}
+
+// Done returns a channel that is closed once the result is available
+func (f *Future[Out]) Done() <-chan struct{} {
+    return f.done
+}
+
+// Wait blocks until the task finishes, ctx is done or the pool is stopped.
+// A result returned by Wait is no longer delivered by the results stream.
+func (f *Future[Out]) Wait(ctx context.Context) (TypedResult[Out], error) {
+    select {
+    case <-f.done:
+        f.claim(f.id)
+        return f.result, nil
+    case <-ctx.Done():
+        return TypedResult[Out]{}, ctx.Err()
+    case <-f.stopped:
+        // the task may have finished just before Stop
+        select {
+        case <-f.done:
+            f.claim(f.id)
+            return f.result, nil
+        default:
+            return TypedResult[Out]{}, ErrPoolStopped
+        }
+    }
+}
+
+// complete stores the result and wakes up waiters
+func (f *Future[Out]) complete(result TypedResult[Out]) {
+    f.result = result
+    close(f.done)
+}
+
+// WithResultStream makes the pool deliver every result that is not waited on
+// through its future to GetResult, in completion order. The stream holds up to
+// size unread results, or as many as the queue for a size below 1; workers wait
+// for a reader while it is full. Without this option results only reach futures.
+func WithResultStream(size int) PoolOption {
+    return func(c *poolConfig) {
+        c.stream = true
+        c.streamSize = size
+    }
+}
+
+// resultStream is a bounded queue of finished results in completion order.
+// Results are removed when read or when their future is waited on, so a full
+// stream only holds back workers while results are neither read nor waited on.
+type resultStream[Out any] struct {
+    mu      sync.Mutex
+    order   *list.List
+    byID    map[uint64]*list.Element
+    pending map[uint64]bool // completed but not yet pushed; true once waited on
+    limit   int
+    ready   chan struct{}
+    changed chan struct{} // closed and replaced whenever a result is read or removed
+}
+
+func newResultStream[Out any](limit int) *resultStream[Out] {
+    return &resultStream[Out]{
+        order:   list.New(),
+        byID:    make(map[uint64]*list.Element),
+        pending: make(map[uint64]bool),
+        limit:   limit,
+        ready:   make(chan struct{}, 1),
+        changed: make(chan struct{}),
+    }
+}
+
+// expect announces the result of a task before its future completes, so that
+// waiting on the future keeps the result out of the stream
+func (s *resultStream[Out]) expect(id uint64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.pending[id] = false
+}
+
+// push appends an expected result and signals a waiting reader, unless its
+// future was waited on. While the stream is full it waits for a result to be
+// read or removed, or for done to be closed, in which case the result is dropped.
+func (s *resultStream[Out]) push(result TypedResult[Out], done <-chan struct{}) {
+    for {
+        s.mu.Lock()
+        if claimed := s.pending[result.ID]; claimed || s.order.Len() < s.limit {
+            if !claimed {
+                s.byID[result.ID] = s.order.PushBack(result)
+            }
+            delete(s.pending, result.ID)
+            s.mu.Unlock()
+            if !claimed {
+                signal(s.ready)
+            }
+            return
+        }
+        changed := s.changed
+        s.mu.Unlock()
+
+        select {
+        case <-changed:
+        case <-done:
+            s.mu.Lock()
+            delete(s.pending, result.ID)
+            s.mu.Unlock()
+            return
+        }
+    }
+}
+
+// remove drops the result of the given task if it has not been read yet, or
+// keeps it from being added if it has not reached the stream
+func (s *resultStream[Out]) remove(id uint64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.pending[id]; ok {
+        s.pending[id] = true
+        s.broadcast() // its worker may be waiting for room
+        return
+    }
+    elem, ok := s.byID[id]
+    if !ok {
+        return
+    }
+    s.order.Remove(elem)
+    delete(s.byID, id)
+    s.broadcast()
+}
+
+// broadcast wakes every worker waiting in push; s.mu must be held
+func (s *resultStream[Out]) broadcast() {
+    close(s.changed)
+    s.changed = make(chan struct{})
+}
+
+// pop waits for the oldest unread result until done is closed
+func (s *resultStream[Out]) pop(done <-chan struct{}) (TypedResult[Out], bool) {
+    for {
+        s.mu.Lock()
+        if front := s.order.Front(); front != nil {
+            s.order.Remove(front)
+            result := front.Value.(TypedResult[Out])
+            delete(s.byID, result.ID)
+            s.broadcast()
+            more := s.order.Len() > 0
+            s.mu.Unlock()
+            if more {
+                signal(s.ready) // pass the wake-up on to the next reader
+            }
+            return result, true
+        }
+        s.mu.Unlock()
+
+        select {
+        case <-s.ready:
+        case <-done:
+            return TypedResult[Out]{}, false
+        }
+    }
+}
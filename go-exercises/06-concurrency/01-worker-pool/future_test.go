@@ -0,0 +1,205 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestFutureWait(t *testing.T) {
+    pool := NewPool(3, process)
+    pool.Start()
+    defer pool.Stop()
+
+    // later tasks finish first, but each future gets its own result
+    var futures []*Future[string]
+    for i, d := range []time.Duration{60, 40, 20, 0} {
+        task := TestTask{ID: i, Result: string(rune('a' + i)), Duration: d * time.Millisecond}
+        future, err := pool.Submit(task)
+        if err != nil {
+            t.Fatalf("Failed to submit task %d: %v", i, err)
+        }
+        futures = append(futures, future)
+    }
+
+    ids := make(map[uint64]bool)
+    for i, future := range futures {
+        result, err := future.Wait(context.Background())
+        if err != nil {
+            t.Fatalf("Wait for task %d failed: %v", i, err)
+        }
+        if want := string(rune('a' + i)); result.Value != want {
+            t.Errorf("task %d: got %q, want %q", i, result.Value, want)
+        }
+        if result.ID != future.ID() || ids[result.ID] {
+            t.Errorf("task %d: result ID %d, future ID %d", i, result.ID, future.ID())
+        }
+        ids[result.ID] = true
+    }
+}
+
+func TestFutureDone(t *testing.T) {
+    pool := NewPool(1, process)
+    pool.Start()
+    defer pool.Stop()
+
+    future, _ := pool.Submit(TestTask{Result: "done", Duration: 20 * time.Millisecond})
+    select {
+    case <-future.Done():
+        t.Fatal("Done closed before the task finished")
+    default:
+    }
+
+    select {
+    case <-future.Done():
+    case <-time.After(time.Second):
+        t.Fatal("Done not closed after the task finished")
+    }
+    if result, err := future.Wait(context.Background()); err != nil || result.Value != "done" {
+        t.Errorf("Wait after Done = %+v, %v", result, err)
+    }
+}
+
+func TestFutureWaitCancelled(t *testing.T) {
+    release := make(chan struct{})
+    pool := NewPool(1, func(_ context.Context, n int) (int, error) {
+        <-release
+        return n, nil
+    })
+    pool.Start()
+
+    future, _ := pool.Submit(1)
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+    if _, err := future.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+        t.Errorf("expected context.DeadlineExceeded, got %v", err)
+    }
+
+    // a queued task never runs once the pool is stopped
+    queued, _ := pool.Submit(2)
+    close(release)
+    pool.Stop()
+    if _, err := queued.Wait(context.Background()); err != nil && !errors.Is(err, ErrPoolStopped) {
+        t.Errorf("expected a result or ErrPoolStopped, got %v", err)
+    }
+}
+
+func TestResultStream(t *testing.T) {
+    pool := NewPool(2, process, WithResultStream(0))
+    pool.Start()
+    defer pool.Stop()
+
+    // results waited on through their future are not delivered twice
+    waited, _ := pool.Submit(TestTask{Result: "waited"})
+    if _, err := waited.Wait(context.Background()); err != nil {
+        t.Fatalf("Wait failed: %v", err)
+    }
+    streamed, _ := pool.Submit(TestTask{Result: "streamed", Duration: 10 * time.Millisecond})
+    result, err := pool.GetResult()
+    if err != nil || result.Value != "streamed" || result.ID != streamed.ID() {
+        t.Errorf("GetResult = %+v, %v; want the streamed task", result, err)
+    }
+
+    // futures still resolve after their result was read from the stream
+    if result, err := streamed.Wait(context.Background()); err != nil || result.Value != "streamed" {
+        t.Errorf("Wait after GetResult = %+v, %v", result, err)
+    }
+}
+
+func TestFuturesDoNotBlockWorkers(t *testing.T) {
+    for _, opts := range [][]PoolOption{nil, {WithResultStream(1)}} {
+        pool := NewPool(2, process, opts...)
+        pool.Start()
+
+        // many more results than the queue and stream hold, none read from the stream
+        const numTasks = 50
+        var wg sync.WaitGroup
+        for i := 0; i < numTasks; i++ {
+            future, err := pool.Submit(TestTask{ID: i, Result: "ok"})
+            if err != nil {
+                t.Fatalf("Failed to submit task %d: %v", i, err)
+            }
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+                defer cancel()
+                if _, err := future.Wait(ctx); err != nil {
+                    t.Errorf("Wait failed: %v", err)
+                }
+            }()
+        }
+        wg.Wait()
+        pool.Stop()
+
+        if pool.results == nil {
+            if _, err := pool.GetResult(); !errors.Is(err, ErrNoResultStream) {
+                t.Errorf("expected ErrNoResultStream without a stream, got %v", err)
+            }
+            continue
+        }
+        pool.results.mu.Lock()
+        left, pending := pool.results.order.Len(), len(pool.results.pending)
+        pool.results.mu.Unlock()
+        if left != 0 || pending != 0 {
+            t.Errorf("expected waited results to leave the stream, %d left and %d pending", left, pending)
+        }
+    }
+}
+
+func TestResultStreamBackpressure(t *testing.T) {
+    pool := NewPool(1, process, WithResultStream(1))
+    pool.Start()
+    defer pool.Stop()
+
+    var futures []*Future[string]
+    for _, name := range []string{"a", "b", "c"} {
+        future, _ := pool.Submit(TestTask{Result: name})
+        futures = append(futures, future)
+    }
+
+    // "a" fills the stream, so the worker waits to deliver "b" and cannot start "c"
+    <-futures[1].Done()
+    select {
+    case <-futures[2].Done():
+        t.Fatal("the worker ran a task while the stream was full")
+    case <-time.After(20 * time.Millisecond):
+    }
+
+    // waiting on "b" takes it out of the stream and frees the worker
+    if result, err := futures[1].Wait(context.Background()); err != nil || result.Value != "b" {
+        t.Errorf("Wait = %+v, %v; want b", result, err)
+    }
+    select {
+    case <-futures[2].Done():
+    case <-time.After(time.Second):
+        t.Fatal("the worker is still waiting for room in the stream")
+    }
+
+    for _, want := range []string{"a", "c"} {
+        if result, err := pool.GetResult(); err != nil || result.Value != want {
+            t.Errorf("GetResult = %+v, %v; want %q", result, err, want)
+        }
+    }
+}
+
+func TestTaskHandle(t *testing.T) {
+    pool := NewWorkerPool(2)
+    pool.Start()
+    defer pool.Stop()
+
+    slow, _ := pool.Submit(TestTask{Result: "slow", Duration: 50 * time.Millisecond})
+    fast, _ := pool.Submit(TestTask{Result: "fast"})
+
+    <-fast.Done()
+    result, err := fast.Wait(context.Background())
+    if err != nil || result.Value != "fast" || result.ID != fast.ID() {
+        t.Errorf("fast task = %+v, %v", result, err)
+    }
+    result, err = slow.Wait(context.Background())
+    if err != nil || result.Value != "slow" || result.ID != slow.ID() {
+        t.Errorf("slow task = %+v, %v", result, err)
+    }
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+    "fmt"
+    "runtime/debug"
+)
+
+// PanicError is the result error of a task that panicked
+type PanicError struct {
+    ID    uint64
+    Value interface{}
+    Stack []byte
+}
+
+func (e *PanicError) Error() string {
+    return fmt.Sprintf("task %d panicked: %v", e.ID, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+    err, _ := e.Value.(error)
+    return err
+}
+
+// WithPanicHandler sets a function called with every task panic, after the
+// task's result has been delivered. A panicking handler kills its worker,
+// which is then restarted.
+func WithPanicHandler(handler func(*PanicError)) PoolOption {
+    return func(c *poolConfig) {
+        c.onPanic = handler
+    }
+}
+
+// recoverTask converts a panic on a goroutine outside the pool into a
+// *PanicError, so it can be re-raised on the worker that waits for it
+func recoverTask(panicked chan<- *PanicError) {
+    if r := recover(); r != nil {
+        panicked <- &PanicError{Value: r, Stack: debug.Stack()}
+    }
+}
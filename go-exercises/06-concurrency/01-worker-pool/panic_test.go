@@ -0,0 +1,161 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// PanicTask panics with its value when executed
+type PanicTask struct {
+    Value interface{}
+}
+
+func (t PanicTask) Execute() Result {
+    panic(t.Value)
+}
+
+func TestPoolRecoversPanics(t *testing.T) {
+    errBoom := errors.New("boom")
+    pool := NewPool(1, func(_ context.Context, v interface{}) (string, error) {
+        if v != nil {
+            panic(v)
+        }
+        return "ok", nil
+    })
+    pool.Start()
+    defer pool.Stop()
+
+    tests := []struct {
+        name    string
+        value   interface{}
+        wantErr error
+    }{
+        {"string value", "bad input", nil},
+        {"error value", errBoom, errBoom},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            future, _ := pool.Submit(tt.value)
+            result, err := future.Wait(context.Background())
+            if err != nil {
+                t.Fatalf("Wait failed: %v", err)
+            }
+            var panicErr *PanicError
+            if !errors.As(result.Error, &panicErr) {
+                t.Fatalf("expected *PanicError, got %v", result.Error)
+            }
+            if panicErr.ID != future.ID() || panicErr.Value != tt.value {
+                t.Errorf("unexpected panic error %+v", panicErr)
+            }
+            if !strings.Contains(string(panicErr.Stack), "TestPoolRecoversPanics") {
+                t.Errorf("stack does not include the panicking function:\n%s", panicErr.Stack)
+            }
+            if tt.wantErr != nil && !errors.Is(result.Error, tt.wantErr) {
+                t.Errorf("expected %v to wrap %v", result.Error, tt.wantErr)
+            }
+        })
+    }
+
+    // the worker survives and keeps processing
+    future, _ := pool.Submit(nil)
+    if result, err := future.Wait(context.Background()); err != nil || result.Value != "ok" {
+        t.Errorf("task after panics = %+v, %v", result, err)
+    }
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+    var panics atomic.Int32
+    pool := NewWorkerPool(2, WithPanicHandler(func(*PanicError) { panics.Add(1) }))
+    pool.Start()
+    defer pool.Stop()
+
+    handle, _ := pool.Submit(PanicTask{Value: "legacy"})
+    result, err := handle.Wait(context.Background())
+    if err != nil {
+        t.Fatalf("Wait failed: %v", err)
+    }
+    var panicErr *PanicError
+    if !errors.As(result.Error, &panicErr) || panicErr.Value != "legacy" || panicErr.ID != handle.ID() {
+        t.Fatalf("expected *PanicError for the task, got %v", result.Error)
+    }
+    if !strings.Contains(string(panicErr.Stack), "PanicTask.Execute") {
+        t.Errorf("stack does not include Execute:\n%s", panicErr.Stack)
+    }
+
+    handle, _ = pool.Submit(TestTask{Result: "after"})
+    if result, err := handle.Wait(context.Background()); err != nil || result.Value != "after" {
+        t.Errorf("task after panic = %+v, %v", result, err)
+    }
+    if n := panics.Load(); n != 1 {
+        t.Errorf("panic handler called %d times, want 1", n)
+    }
+    if n := pool.Restarts(); n != 0 {
+        t.Errorf("%d worker restarts after a recovered panic, want 0", n)
+    }
+}
+
+func TestPoolRestartsDeadWorkers(t *testing.T) {
+    const numWorkers = 3
+    var running, peak atomic.Int32
+    release := make(chan struct{})
+    pool := NewPool(numWorkers, func(_ context.Context, block bool) (int, error) {
+        if !block {
+            panic("task failed")
+        }
+        n := running.Add(1)
+        for {
+            old := peak.Load()
+            if n <= old || peak.CompareAndSwap(old, n) {
+                break
+            }
+        }
+        <-release
+        running.Add(-1)
+        return 0, nil
+    }, WithPanicHandler(func(*PanicError) {
+        panic("handler failed") // kills the worker
+    }))
+    pool.Start()
+    defer pool.Stop()
+
+    for i := 0; i < numWorkers; i++ {
+        future, _ := pool.Submit(false)
+        if result, _ := future.Wait(context.Background()); result.Error == nil {
+            t.Fatal("expected a panic error")
+        }
+    }
+
+    // every worker died once; all slots must be back to run blocking tasks together
+    var futures []*Future[int]
+    for i := 0; i < numWorkers; i++ {
+        future, _ := pool.Submit(true)
+        futures = append(futures, future)
+    }
+    deadline := time.Now().Add(time.Second)
+    for running.Load() < numWorkers && time.Now().Before(deadline) {
+        time.Sleep(time.Millisecond)
+    }
+    close(release)
+
+    var wg sync.WaitGroup
+    for _, future := range futures {
+        wg.Add(1)
+        go func(future *Future[int]) {
+            defer wg.Done()
+            future.Wait(context.Background())
+        }(future)
+    }
+    wg.Wait()
+
+    if n := peak.Load(); n != numWorkers {
+        t.Errorf("peak concurrency %d, want %d", n, numWorkers)
+    }
+    if n := pool.Restarts(); n != numWorkers {
+        t.Errorf("%d worker restarts, want %d", n, numWorkers)
+    }
+}
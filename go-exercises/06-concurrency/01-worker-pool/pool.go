@@ -0,0 +1,377 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "runtime/debug"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Errors returned by Pool and WorkerPool
+var (
+    ErrPoolStopped    = errors.New("worker pool stopped")
+    ErrInvalidSize    = errors.New("invalid number of workers")
+    ErrNoResultStream = errors.New("result stream not enabled")
+)
+
+// TimeoutError is the result error of a task that failed after its deadline passed
+type TimeoutError struct {
+    ID      uint64
+    Timeout time.Duration
+    Err     error
+}
+
+func (e *TimeoutError) Error() string {
+    return fmt.Sprintf("task %d timed out after %v: %v", e.ID, e.Timeout, e.Err)
+}
+
+// Unwrap returns context.DeadlineExceeded and the task's error, so errors.Is matches either
+func (e *TimeoutError) Unwrap() []error {
+    return []error{context.DeadlineExceeded, e.Err}
+}
+
+// TypedResult holds the output of one task of a Pool
+type TypedResult[Out any] struct {
+    ID       uint64
+    Value    Out
+    Error    error
+    Attempts int
+    Errors   []error // the error of every failed attempt
+}
+
+// job is a queued input together with the future for its result
+type job[In, Out any] struct {
+    in        In
+    timeout   time.Duration
+    submitted time.Time
+    future    *Future[Out]
+}
+
+// PoolOption configures a Pool or WorkerPool
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+    onPanic        func(*PanicError)
+    retry          RetryPolicy
+    clock          Clock
+    queueSize      int
+    priorityLevels int
+    agingInterval  time.Duration
+    autoscale      *AutoscalerConfig
+    stream         bool
+    streamSize     int
+}
+
+// Pool runs a function on submitted inputs with a resizable number of worker goroutines
+type Pool[In, Out any] struct {
+    mu         sync.Mutex // guards numWorkers, started and slots
+    numWorkers int
+    started    bool
+    slots      []context.CancelFunc // stops the worker in each slot
+    fn         func(context.Context, In) (Out, error)
+    config     poolConfig
+    queue      *scheduler[job[In, Out]]
+    results    *resultStream[Out] // nil unless WithResultStream is set
+    scaler     *autoscaler
+    nextID     atomic.Uint64
+    restarts   atomic.Uint64
+    wg         sync.WaitGroup
+    ctx        context.Context
+    cancel     context.CancelFunc
+    stopOnce   sync.Once
+}
+
+// NewPool creates a pool whose workers call fn for every submitted input
+func NewPool[In, Out any](numWorkers int, fn func(context.Context, In) (Out, error), opts ...PoolOption) *Pool[In, Out] {
+    ctx, cancel := context.WithCancel(context.Background())
+    p := &Pool[In, Out]{
+        numWorkers: numWorkers,
+        fn:         fn,
+        ctx:        ctx,
+        cancel:     cancel,
+        config: poolConfig{
+            clock:          realClock{},
+            priorityLevels: DefaultPriorityLevels,
+            agingInterval:  DefaultAgingInterval,
+        },
+    }
+    for _, opt := range opts {
+        opt(&p.config)
+    }
+    if p.config.autoscale != nil {
+        p.scaler = newAutoscaler(*p.config.autoscale)
+        p.numWorkers = p.scaler.clamp(p.numWorkers)
+    }
+    if p.config.queueSize == 0 {
+        p.config.queueSize = max(numWorkers*2, 1)
+        if p.scaler != nil {
+            p.config.queueSize = p.scaler.queueSize()
+        }
+    }
+    p.config.priorityLevels = max(p.config.priorityLevels, 1)
+    p.queue = newScheduler[job[In, Out]](p.config.queueSize, p.config.priorityLevels, p.config.agingInterval, p.config.clock)
+    if p.config.stream {
+        if p.config.streamSize < 1 {
+            p.config.streamSize = p.config.queueSize
+        }
+        p.results = newResultStream[Out](p.config.streamSize)
+    }
+    return p
+}
+
+// Start starts the worker goroutines, and the autoscaler if there is one
+func (p *Pool[In, Out]) Start() {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.started {
+        return
+    }
+    p.started = true
+    for len(p.slots) < p.numWorkers {
+        p.addWorker()
+    }
+    if p.scaler != nil {
+        p.wg.Add(1)
+        go p.autoscale()
+    }
+}
+
+// Stop cancels the pool's context and waits for the workers to return.
+// Tasks still queued are dropped and waiting on their futures returns
+// ErrPoolStopped; it is safe to call Stop more than once.
+func (p *Pool[In, Out]) Stop() {
+    p.stopOnce.Do(func() {
+        p.mu.Lock()
+        p.cancel() // under the lock, so Resize cannot add workers during Wait
+        p.mu.Unlock()
+        p.wg.Wait()
+    })
+}
+
+// Workers returns the number of workers the pool runs
+func (p *Pool[In, Out]) Workers() int {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return p.numWorkers
+}
+
+// Restarts returns how many times a worker died from a panic and was restarted
+func (p *Pool[In, Out]) Restarts() uint64 {
+    return p.restarts.Load()
+}
+
+// Resize changes the number of workers. Removed workers finish their current
+// task before they exit, so no task is dropped.
+func (p *Pool[In, Out]) Resize(n int) error {
+    if n < 1 {
+        return fmt.Errorf("%w: %d", ErrInvalidSize, n)
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.ctx.Err() != nil {
+        return ErrPoolStopped
+    }
+    p.resize(n)
+    return nil
+}
+
+// resize starts or stops workers to run n of them; p.mu must be held
+func (p *Pool[In, Out]) resize(n int) {
+    p.numWorkers = n
+    if !p.started {
+        return
+    }
+    for len(p.slots) < n {
+        p.addWorker()
+    }
+    for len(p.slots) > n {
+        last := len(p.slots) - 1
+        p.slots[last]()
+        p.slots = p.slots[:last]
+    }
+}
+
+// addWorker starts a supervised worker in a new slot; p.mu must be held
+func (p *Pool[In, Out]) addWorker() {
+    ctx, cancel := context.WithCancel(p.ctx)
+    p.slots = append(p.slots, cancel)
+    p.wg.Add(1)
+    go p.supervise(ctx.Done())
+}
+
+// Submit queues an input at the default priority, blocking while the queue is
+// full, and returns a future for its result
+func (p *Pool[In, Out]) Submit(in In) (*Future[Out], error) {
+    return p.submit(in, p.queue.defaultPriority(), 0)
+}
+
+// SubmitWithPriority is like Submit, but queues the input at the given
+// priority; higher priorities run first
+func (p *Pool[In, Out]) SubmitWithPriority(in In, priority int) (*Future[Out], error) {
+    return p.submit(in, priority, 0)
+}
+
+// SubmitWithTimeout is like Submit, but cancels the context passed to the
+// task function once timeout has passed since each attempt started. A zero
+// timeout means no deadline.
+func (p *Pool[In, Out]) SubmitWithTimeout(in In, timeout time.Duration) (*Future[Out], error) {
+    return p.submit(in, p.queue.defaultPriority(), timeout)
+}
+
+func (p *Pool[In, Out]) submit(in In, priority int, timeout time.Duration) (*Future[Out], error) {
+    if p.ctx.Err() != nil {
+        return nil, ErrPoolStopped
+    }
+    future := &Future[Out]{
+        id:      p.nextID.Add(1),
+        done:    make(chan struct{}),
+        stopped: p.ctx.Done(),
+        claim:   func(uint64) {},
+    }
+    if p.results != nil {
+        future.claim = p.results.remove
+    }
+    j := job[In, Out]{in: in, timeout: timeout, submitted: p.config.clock.Now(), future: future}
+    if err := p.queue.push(j, priority, p.ctx.Done()); err != nil {
+        return nil, err
+    }
+    return future, nil
+}
+
+// GetResult waits for the next result that has not been waited on through its
+// future, in completion order. It needs WithResultStream.
+func (p *Pool[In, Out]) GetResult() (TypedResult[Out], error) {
+    if p.results == nil {
+        return TypedResult[Out]{}, ErrNoResultStream
+    }
+    if p.ctx.Err() != nil {
+        return TypedResult[Out]{}, ErrPoolStopped
+    }
+    result, ok := p.results.pop(p.ctx.Done())
+    if !ok {
+        return TypedResult[Out]{}, ErrPoolStopped
+    }
+    return result, nil
+}
+
+// supervise keeps a worker running in a slot until quit is closed,
+// restarting it whenever it dies from a panic
+func (p *Pool[In, Out]) supervise(quit <-chan struct{}) {
+    defer p.wg.Done()
+
+    for !p.worker(quit) {
+        p.restarts.Add(1)
+    }
+}
+
+// worker processes inputs from the queue. It reports whether it returned
+// because quit was closed rather than because of a panic.
+func (p *Pool[In, Out]) worker(quit <-chan struct{}) (stopped bool) {
+    defer func() {
+        if recover() != nil {
+            stopped = false
+        }
+    }()
+
+    for {
+        j, ok := p.queue.pop(quit)
+        if !ok {
+            return true
+        }
+        result := p.run(j)
+        if p.results != nil {
+            // completing the future first lets Wait free a full stream
+            p.results.expect(result.ID)
+            j.future.complete(result)
+            p.results.push(result, p.ctx.Done())
+        } else {
+            j.future.complete(result)
+        }
+        if p.scaler != nil {
+            p.scaler.observe(p.config.clock.Now().Sub(j.submitted))
+        }
+
+        if p.config.onPanic != nil {
+            errs := result.Errors
+            if n := len(errs); result.Error != nil && (n == 0 || errs[n-1] != result.Error) {
+                errs = append(errs[:n:n], result.Error) // a panic in RetryIf is no attempt's error
+            }
+            for _, err := range errs {
+                var panicErr *PanicError
+                if errors.As(err, &panicErr) {
+                    p.config.onPanic(panicErr)
+                }
+            }
+        }
+    }
+}
+
+// run makes attempts at a task as allowed by the retry policy
+func (p *Pool[In, Out]) run(j job[In, Out]) TypedResult[Out] {
+    result := TypedResult[Out]{ID: j.future.id}
+    for {
+        result.Value, result.Error = p.attempt(j)
+        result.Attempts++
+        if result.Error == nil {
+            return result
+        }
+        result.Errors = append(result.Errors, result.Error)
+        retry, panicErr := p.retries(j, result)
+        if panicErr != nil {
+            result.Error = panicErr
+            return result
+        }
+        if !retry || p.ctx.Err() != nil {
+            return result
+        }
+
+        select {
+        case <-p.config.clock.After(p.config.retry.backoff(result.Attempts)):
+        case <-p.ctx.Done():
+            return result
+        }
+    }
+}
+
+// retries asks the retry policy whether to retry after a failed attempt. A panic
+// in RetryIf is returned as a *PanicError, so the task still gets a result.
+func (p *Pool[In, Out]) retries(j job[In, Out], result TypedResult[Out]) (retry bool, panicErr *PanicError) {
+    defer func() {
+        if r := recover(); r != nil {
+            retry, panicErr = false, &PanicError{ID: j.future.id, Value: r, Stack: debug.Stack()}
+        }
+    }()
+    return p.config.retry.retries(result.Attempts, result.Error), nil
+}
+
+// attempt calls the task function with the pool's context, limited by the
+// task's timeout, and turns a panic into a *PanicError
+func (p *Pool[In, Out]) attempt(j job[In, Out]) (value Out, err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            panicErr, ok := r.(*PanicError)
+            if !ok {
+                panicErr = &PanicError{Value: r, Stack: debug.Stack()}
+            }
+            panicErr.ID = j.future.id
+            var zero Out
+            value, err = zero, panicErr
+        }
+    }()
+
+    ctx := p.ctx
+    if j.timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(p.ctx, j.timeout)
+        defer cancel()
+    }
+
+    value, err = p.fn(ctx, j.in)
+    if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+        err = &TimeoutError{ID: j.future.id, Timeout: j.timeout, Err: err}
+    }
+    return value, err
+}
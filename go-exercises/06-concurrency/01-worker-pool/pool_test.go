@@ -0,0 +1,246 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+)
+
+// process is a typed task function for testing: it sleeps for the input's
+// duration and returns its result or error
+func process(_ context.Context, task TestTask) (string, error) {
+    if task.Duration > 0 {
+        time.Sleep(task.Duration)
+    }
+    return task.Result, task.Error
+}
+
+func TestNewPool(t *testing.T) {
+    pool := NewPool(3, process)
+    if pool == nil {
+        t.Fatal("NewPool returned nil")
+    }
+    if pool.numWorkers != 3 {
+        t.Errorf("Expected 3 workers, got %d", pool.numWorkers)
+    }
+}
+
+func TestPoolBasic(t *testing.T) {
+    pool := NewPool(2, process, WithResultStream(0))
+    pool.Start()
+    defer pool.Stop()
+
+    tasks := []TestTask{
+        {ID: 1, Result: "Task 1", Duration: 100 * time.Millisecond},
+        {ID: 2, Result: "Task 2", Duration: 100 * time.Millisecond},
+        {ID: 3, Result: "Task 3", Duration: 100 * time.Millisecond},
+    }
+    for _, task := range tasks {
+        if _, err := pool.Submit(task); err != nil {
+            t.Errorf("Failed to submit task %d: %v", task.ID, err)
+        }
+    }
+
+    seen := make(map[string]bool)
+    for range tasks {
+        result, err := pool.GetResult()
+        if err != nil {
+            t.Errorf("Failed to get result: %v", err)
+            continue
+        }
+        if result.Error != nil {
+            t.Errorf("Task returned error: %v", result.Error)
+        }
+        seen[result.Value] = true // a string, no type assertion needed
+    }
+    for _, task := range tasks {
+        if !seen[task.Result] {
+            t.Errorf("Missing result %q", task.Result)
+        }
+    }
+}
+
+func TestPoolConcurrent(t *testing.T) {
+    pool := NewPool(5, process, WithResultStream(0))
+    pool.Start()
+    defer pool.Stop()
+
+    var wg sync.WaitGroup
+    numTasks := 10
+    results := make(chan string, numTasks)
+
+    for i := 0; i < numTasks; i++ {
+        wg.Add(1)
+        go func(id int) {
+            defer wg.Done()
+            task := TestTask{ID: id, Result: "Task " + strconv.Itoa(id), Duration: 50 * time.Millisecond}
+            if _, err := pool.Submit(task); err != nil {
+                t.Errorf("Failed to submit task %d: %v", id, err)
+                return
+            }
+            result, err := pool.GetResult()
+            if err != nil {
+                t.Errorf("Failed to get result for task %d: %v", id, err)
+                return
+            }
+            if result.Error != nil {
+                t.Errorf("Task %d returned error: %v", id, result.Error)
+                return
+            }
+            results <- result.Value
+        }(i)
+    }
+
+    wg.Wait()
+    close(results)
+
+    var count int
+    for range results {
+        count++
+    }
+    if count != numTasks {
+        t.Errorf("Expected %d results, got %d", numTasks, count)
+    }
+}
+
+func TestPoolErrorHandling(t *testing.T) {
+    pool := NewPool(2, process, WithResultStream(0))
+    pool.Start()
+    defer pool.Stop()
+
+    taskErr := errors.New("task error")
+    if _, err := pool.Submit(TestTask{ID: 1, Result: "Error Task", Error: taskErr}); err != nil {
+        t.Errorf("Failed to submit task: %v", err)
+    }
+
+    result, err := pool.GetResult()
+    if err != nil {
+        t.Fatalf("Failed to get result: %v", err)
+    }
+    if !errors.Is(result.Error, taskErr) {
+        t.Errorf("Expected error %v, got %v", taskErr, result.Error)
+    }
+    if result.Value != "Error Task" {
+        t.Errorf("Expected value %q alongside the error, got %q", "Error Task", result.Value)
+    }
+}
+
+func TestPoolGracefulShutdown(t *testing.T) {
+    pool := NewPool(3, process, WithResultStream(0))
+    pool.Start()
+
+    for i := 0; i < 5; i++ {
+        task := TestTask{ID: i, Result: "Task " + strconv.Itoa(i), Duration: 100 * time.Millisecond}
+        if _, err := pool.Submit(task); err != nil {
+            t.Errorf("Failed to submit task %d: %v", i, err)
+        }
+    }
+
+    pool.Stop()
+    pool.Stop() // a second Stop is a no-op
+
+    if _, err := pool.Submit(TestTask{ID: 6, Result: "Task 6"}); !errors.Is(err, ErrPoolStopped) {
+        t.Errorf("Expected ErrPoolStopped when submitting after stopping, got %v", err)
+    }
+    if _, err := pool.GetResult(); !errors.Is(err, ErrPoolStopped) {
+        t.Errorf("Expected ErrPoolStopped when getting a result after stopping, got %v", err)
+    }
+}
+
+func TestPoolPassesContext(t *testing.T) {
+    pool := NewPool(1, func(ctx context.Context, n int) (int, error) {
+        if ctx == nil {
+            return 0, errors.New("nil context")
+        }
+        return n * n, ctx.Err()
+    }, WithResultStream(0))
+    pool.Start()
+    defer pool.Stop()
+
+    pool.Submit(7)
+    result, err := pool.GetResult()
+    if err != nil || result.Error != nil || result.Value != 49 {
+        t.Errorf("got %+v, %v; want 49", result, err)
+    }
+}
+
+func TestPoolTimeout(t *testing.T) {
+    // the task reports its own error rather than ctx.Err()
+    errAbandoned := errors.New("abandoned")
+    pool := NewPool(1, func(ctx context.Context, d time.Duration) (string, error) {
+        select {
+        case <-time.After(d):
+            return "finished", nil
+        case <-ctx.Done():
+            return "", errAbandoned
+        }
+    })
+    pool.Start()
+    defer pool.Stop()
+
+    tests := []struct {
+        name     string
+        duration time.Duration
+        timeout  time.Duration
+        expected string
+        wantErr  error
+    }{
+        {"no deadline", 10 * time.Millisecond, 0, "finished", nil},
+        {"within deadline", 0, time.Second, "finished", nil},
+        {"deadline passed", time.Hour, 20 * time.Millisecond, "", errAbandoned},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            future, err := pool.SubmitWithTimeout(tt.duration, tt.timeout)
+            if err != nil {
+                t.Fatalf("Failed to submit: %v", err)
+            }
+            result, err := future.Wait(context.Background())
+            if err != nil {
+                t.Fatalf("Wait failed: %v", err)
+            }
+            if tt.wantErr != nil {
+                var timeoutErr *TimeoutError
+                if !errors.Is(result.Error, tt.wantErr) || !errors.As(result.Error, &timeoutErr) {
+                    t.Fatalf("expected *TimeoutError wrapping %v, got %v", tt.wantErr, result.Error)
+                }
+                if !errors.Is(result.Error, context.DeadlineExceeded) {
+                    t.Errorf("expected %v to match context.DeadlineExceeded", result.Error)
+                }
+                if timeoutErr.ID != future.ID() || timeoutErr.Timeout != tt.timeout {
+                    t.Errorf("unexpected timeout error %+v", timeoutErr)
+                }
+                return
+            }
+            if result.Error != nil || result.Value != tt.expected {
+                t.Errorf("got %+v, want %q", result, tt.expected)
+            }
+        })
+    }
+}
+
+func TestPoolStopInterruptsTasks(t *testing.T) {
+    started := make(chan struct{})
+    pool := NewPool(1, func(ctx context.Context, _ int) (int, error) {
+        close(started)
+        <-ctx.Done()
+        return 0, ctx.Err()
+    })
+    pool.Start()
+    pool.Submit(1)
+    <-started
+
+    stopped := make(chan struct{})
+    go func() {
+        pool.Stop()
+        close(stopped)
+    }()
+    select {
+    case <-stopped:
+    case <-time.After(time.Second):
+        t.Fatal("Stop did not interrupt the running task")
+    }
+}
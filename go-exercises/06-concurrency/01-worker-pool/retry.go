@@ -0,0 +1,82 @@
+package main
+
+import (
+    "math"
+    "math/rand"
+    "time"
+)
+
+// Clock tells the time for task aging and creates the timers used between
+// retries; tests replace it to avoid sleeping
+type Clock interface {
+    Now() time.Time
+    After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+    return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+    return time.After(d)
+}
+
+// RetryPolicy controls how often and how quickly failed tasks are retried.
+// The zero value makes a single attempt.
+type RetryPolicy struct {
+    MaxAttempts    int                  // attempts including the first; values below 1 mean 1
+    InitialBackoff time.Duration        // wait before the second attempt
+    MaxBackoff     time.Duration        // upper bound on the wait; zero means the longest Duration
+    Multiplier     float64              // growth of the wait per attempt; values below 1 mean 2
+    Jitter         float64              // fraction of the wait, in [0, 1], that is randomised
+    RetryIf        func(err error) bool // whether err is worth retrying; nil retries every error
+}
+
+// WithRetry sets the retry policy for failed tasks
+func WithRetry(policy RetryPolicy) PoolOption {
+    return func(c *poolConfig) {
+        c.retry = policy
+    }
+}
+
+// WithClock sets the clock used for aging and for waits between retries
+func WithClock(clock Clock) PoolOption {
+    return func(c *poolConfig) {
+        c.clock = clock
+    }
+}
+
+// retries reports whether another attempt follows the given failed one
+func (r RetryPolicy) retries(attempts int, err error) bool {
+    if attempts >= r.MaxAttempts {
+        return false
+    }
+    return r.RetryIf == nil || r.RetryIf(err)
+}
+
+// backoff returns the wait after the given number of failed attempts:
+// exponential growth capped at MaxBackoff, reduced by up to Jitter of itself.
+// Without MaxBackoff the wait stops growing at the longest time.Duration.
+func (r RetryPolicy) backoff(attempts int) time.Duration {
+    if r.InitialBackoff <= 0 {
+        return 0
+    }
+    multiplier := r.Multiplier
+    if multiplier < 1 {
+        multiplier = 2
+    }
+    limit := float64(math.MaxInt64)
+    if r.MaxBackoff > 0 {
+        limit = float64(r.MaxBackoff)
+    }
+    wait := math.Min(float64(r.InitialBackoff)*math.Pow(multiplier, float64(attempts-1)), limit)
+    jitter := math.Min(math.Max(r.Jitter, 0), 1)
+    wait -= wait * jitter * rand.Float64()
+    if wait >= float64(math.MaxInt64) {
+        // float64(math.MaxInt64) rounds up to 2^63, which does not fit a Duration
+        return time.Duration(math.MaxInt64)
+    }
+    return time.Duration(wait)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "math"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// fakeClock only moves when advanced, fires every timer immediately and
+// records the requested waits
+type fakeClock struct {
+    mu    sync.Mutex
+    now   time.Time
+    waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+    c.mu.Lock()
+    c.now = c.now.Add(d)
+    c.mu.Unlock()
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+    c.mu.Lock()
+    c.waits = append(c.waits, d)
+    c.mu.Unlock()
+    ch := make(chan time.Time, 1)
+    ch <- time.Time{}
+    return ch
+}
+
+func (c *fakeClock) Waits() []time.Duration {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return append([]time.Duration(nil), c.waits...)
+}
+
+// flaky returns a task function that fails with errs in turn, then succeeds
+func flaky(errs ...error) func(context.Context, int) (string, error) {
+    var calls atomic.Int32
+    return func(context.Context, int) (string, error) {
+        n := int(calls.Add(1))
+        if n <= len(errs) {
+            return "", errs[n-1]
+        }
+        return "ok", nil
+    }
+}
+
+func TestRetryPolicy(t *testing.T) {
+    errTransient := errors.New("transient")
+    errFatal := errors.New("fatal")
+    policy := RetryPolicy{
+        MaxAttempts:    4,
+        InitialBackoff: 100 * time.Millisecond,
+        MaxBackoff:     300 * time.Millisecond,
+        RetryIf:        func(err error) bool { return !errors.Is(err, errFatal) },
+    }
+
+    tests := []struct {
+        name     string
+        errs     []error
+        policy   RetryPolicy
+        expected string
+        attempts int
+        waits    []time.Duration
+        wantErr  error
+    }{
+        {"no policy", []error{errTransient}, RetryPolicy{}, "", 1, nil, errTransient},
+        {"succeeds first time", nil, policy, "ok", 1, nil, nil},
+        {"recovers", []error{errTransient, errTransient}, policy, "ok", 3,
+            []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, nil},
+        {"runs out of attempts", []error{errTransient, errTransient, errTransient, errTransient, errTransient}, policy, "", 4,
+            []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, errTransient},
+        {"not retryable", []error{errTransient, errFatal}, policy, "", 2,
+            []time.Duration{100 * time.Millisecond}, errFatal},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            clock := &fakeClock{}
+            pool := NewPool(1, flaky(tt.errs...), WithRetry(tt.policy), WithClock(clock))
+            pool.Start()
+            defer pool.Stop()
+
+            future, _ := pool.Submit(0)
+            result, err := future.Wait(context.Background())
+            if err != nil {
+                t.Fatalf("Wait failed: %v", err)
+            }
+            failures := tt.attempts
+            if tt.wantErr == nil {
+                failures--
+            }
+            if result.Attempts != tt.attempts || len(result.Errors) != failures {
+                t.Errorf("got %d attempts and errors %v", result.Attempts, result.Errors)
+            }
+            for i, err := range result.Errors {
+                if !errors.Is(err, tt.errs[i]) {
+                    t.Errorf("error %d: got %v, want %v", i, err, tt.errs[i])
+                }
+            }
+            if tt.wantErr != nil {
+                if !errors.Is(result.Error, tt.wantErr) {
+                    t.Errorf("expected error %v, got %v", tt.wantErr, result.Error)
+                }
+            } else if result.Error != nil || result.Value != tt.expected {
+                t.Errorf("got %+v, want %q", result, tt.expected)
+            }
+
+            waits := clock.Waits()
+            if len(waits) != len(tt.waits) {
+                t.Fatalf("waited %v, want %v", waits, tt.waits)
+            }
+            for i := range waits {
+                if waits[i] != tt.waits[i] {
+                    t.Errorf("wait %d: got %v, want %v", i, waits[i], tt.waits[i])
+                }
+            }
+        })
+    }
+}
+
+func TestRetryBackoffJitter(t *testing.T) {
+    policy := RetryPolicy{InitialBackoff: time.Second, Multiplier: 3, Jitter: 0.5}
+    for attempts, base := range map[int]time.Duration{1: time.Second, 2: 3 * time.Second, 3: 9 * time.Second} {
+        for i := 0; i < 100; i++ {
+            if wait := policy.backoff(attempts); wait < base/2 || wait > base {
+                t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempts, wait, base/2, base)
+            }
+        }
+    }
+}
+
+func TestRetryBackoffLimits(t *testing.T) {
+    tests := []struct {
+        name     string
+        policy   RetryPolicy
+        attempts int
+        expected time.Duration
+    }{
+        {"capped", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 10, time.Minute},
+        {"no cap after many attempts", RetryPolicy{InitialBackoff: time.Second}, 100, math.MaxInt64},
+        {"no cap with a huge multiplier", RetryPolicy{InitialBackoff: time.Second, Multiplier: 1e300}, 3, math.MaxInt64},
+        {"no initial backoff", RetryPolicy{Multiplier: 1e300}, 3, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if wait := tt.policy.backoff(tt.attempts); wait != tt.expected {
+                t.Errorf("backoff(%d) = %v, want %v", tt.attempts, wait, tt.expected)
+            }
+        })
+    }
+}
+
+func TestWorkerPoolRetries(t *testing.T) {
+    clock := &fakeClock{}
+    pool := NewWorkerPool(1, WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}), WithClock(clock))
+    pool.Start()
+    defer pool.Stop()
+
+    // panics are retried like any other error
+    handle, _ := pool.Submit(PanicTask{Value: "always"})
+    result, err := handle.Wait(context.Background())
+    if err != nil {
+        t.Fatalf("Wait failed: %v", err)
+    }
+    var panicErr *PanicError
+    if result.Attempts != 3 || len(result.Errors) != 3 || !errors.As(result.Error, &panicErr) {
+        t.Errorf("got %d attempts, errors %v", result.Attempts, result.Errors)
+    }
+    if waits := clock.Waits(); len(waits) != 2 || waits[0] != time.Minute || waits[1] != 2*time.Minute {
+        t.Errorf("unexpected waits %v", waits)
+    }
+}
+
+func TestRetryIfPanics(t *testing.T) {
+    errDown := errors.New("down")
+    var handled atomic.Int32
+    pool := NewPool(1, flaky(errDown, errDown), WithClock(&fakeClock{}),
+        WithPanicHandler(func(*PanicError) { handled.Add(1) }),
+        WithRetry(RetryPolicy{MaxAttempts: 3, RetryIf: func(error) bool { panic("bad predicate") }}))
+    pool.Start()
+    defer pool.Stop()
+
+    future, _ := pool.Submit(0)
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    result, err := future.Wait(ctx)
+    if err != nil {
+        t.Fatalf("Wait failed: %v", err)
+    }
+    var panicErr *PanicError
+    if !errors.As(result.Error, &panicErr) || panicErr.Value != "bad predicate" || panicErr.ID != future.ID() {
+        t.Errorf("expected a *PanicError from RetryIf, got %v", result.Error)
+    }
+    if result.Attempts != 1 || len(result.Errors) != 1 || !errors.Is(result.Errors[0], errDown) {
+        t.Errorf("got %d attempts, errors %v", result.Attempts, result.Errors)
+    }
+    waitFor(t, "the panic handler", func() bool { return handled.Load() == 1 })
+    if n := pool.Restarts(); n != 0 {
+        t.Errorf("%d worker restarts, want 0", n)
+    }
+}
+
+func TestRetryStopsWithPool(t *testing.T) {
+    pool := NewPool(1, flaky(errors.New("down"), errors.New("down")),
+        WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}))
+    pool.Start()
+    future, _ := pool.Submit(0)
+
+    // Stop must not wait for the hour-long backoff
+    stopped := make(chan struct{})
+    go func() {
+        time.Sleep(10 * time.Millisecond)
+        pool.Stop()
+        close(stopped)
+    }()
+    select {
+    case <-stopped:
+    case <-time.After(time.Second):
+        t.Fatal("Stop waited for the retry backoff")
+    }
+    if result, err := future.Wait(context.Background()); err == nil && result.Attempts != 1 {
+        t.Errorf("expected a single attempt before stopping, got %d", result.Attempts)
+    }
+}
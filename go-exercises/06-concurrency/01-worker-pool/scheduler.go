@@ -0,0 +1,202 @@
+package main
+
+import (
+    "container/heap"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+)
+
+// Default priority settings: Submit uses the middle of three levels
+const (
+    PriorityLow = iota
+    PriorityNormal
+    PriorityHigh
+
+    DefaultPriorityLevels = 3
+    DefaultAgingInterval  = time.Second
+)
+
+// ErrInvalidPriority is returned for a priority outside the configured levels
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// WithPriorityLevels sets the number of priority levels; priorities run from
+// 0 (lowest) to levels-1 and Submit uses levels/2
+func WithPriorityLevels(levels int) PoolOption {
+    return func(c *poolConfig) {
+        c.priorityLevels = levels
+    }
+}
+
+// WithAging sets how long a task has to wait to gain one priority level, so
+// that a steady stream of urgent tasks cannot starve the rest. A zero
+// interval schedules by strict priority.
+func WithAging(interval time.Duration) PoolOption {
+    return func(c *poolConfig) {
+        c.agingInterval = interval
+    }
+}
+
+// WithQueueSize sets how many tasks can wait before Submit blocks, at least one.
+// By default the queue holds two tasks per worker, or enough for an autoscaler
+// to reach MaxWorkers.
+func WithQueueSize(size int) PoolOption {
+    return func(c *poolConfig) {
+        c.queueSize = max(size, 1)
+    }
+}
+
+// entry is a queued value with its scheduling order
+type entry[T any] struct {
+    value    T
+    priority int
+    enqueued time.Time
+    seq      uint64
+}
+
+// scheduler is a bounded priority queue. With aging, a task's effective
+// priority grows by one level per interval waited; since every waiting task
+// ages at the same rate, that order is fixed when the task is queued.
+type scheduler[T any] struct {
+    mu     sync.Mutex
+    items  entryHeap[T]
+    size   int
+    levels int
+    clock  Clock
+    seq    uint64
+    ready  chan struct{}
+    space  chan struct{}
+}
+
+func newScheduler[T any](size, levels int, aging time.Duration, clock Clock) *scheduler[T] {
+    return &scheduler[T]{
+        items:  entryHeap[T]{aging: aging},
+        size:   size,
+        levels: levels,
+        clock:  clock,
+        ready:  make(chan struct{}, 1),
+        space:  make(chan struct{}, 1),
+    }
+}
+
+// defaultPriority is the priority used by Submit
+func (s *scheduler[T]) defaultPriority() int {
+    return s.levels / 2
+}
+
+// push queues a value, waiting for space until done is closed
+func (s *scheduler[T]) push(value T, priority int, done <-chan struct{}) error {
+    if priority < 0 || priority >= s.levels {
+        return fmt.Errorf("%w: %d not in [0, %d]", ErrInvalidPriority, priority, s.levels-1)
+    }
+    for {
+        s.mu.Lock()
+        if len(s.items.entries) < s.size {
+            s.seq++
+            heap.Push(&s.items, entry[T]{value: value, priority: priority, enqueued: s.clock.Now(), seq: s.seq})
+            more := len(s.items.entries) < s.size
+            s.mu.Unlock()
+            signal(s.ready)
+            if more {
+                signal(s.space) // pass the wake-up on to the next submitter
+            }
+            return nil
+        }
+        s.mu.Unlock()
+
+        select {
+        case <-s.space:
+        case <-done:
+            return ErrPoolStopped
+        }
+    }
+}
+
+// pop waits for the most urgent value until done is closed
+func (s *scheduler[T]) pop(done <-chan struct{}) (T, bool) {
+    var zero T
+    for {
+        select {
+        case <-done:
+            signal(s.ready) // a wake-up consumed before quitting belongs to another worker
+            return zero, false
+        default:
+        }
+
+        s.mu.Lock()
+        if len(s.items.entries) > 0 {
+            e := heap.Pop(&s.items).(entry[T])
+            more := len(s.items.entries) > 0
+            s.mu.Unlock()
+            signal(s.space)
+            if more {
+                signal(s.ready)
+            }
+            return e.value, true
+        }
+        s.mu.Unlock()
+
+        select {
+        case <-s.ready:
+        case <-done:
+            return zero, false
+        }
+    }
+}
+
+// len returns the number of queued values
+func (s *scheduler[T]) len() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.items.entries)
+}
+
+// signal wakes up one waiter on ch without blocking
+func signal(ch chan struct{}) {
+    select {
+    case ch <- struct{}{}:
+    default:
+    }
+}
+
+// entryHeap implements heap.Interface, most urgent entry first
+type entryHeap[T any] struct {
+    entries []entry[T]
+    aging   time.Duration
+}
+
+func (h *entryHeap[T]) Len() int {
+    return len(h.entries)
+}
+
+func (h *entryHeap[T]) Less(i, j int) bool {
+    a, b := h.entries[i], h.entries[j]
+    if h.aging > 0 {
+        // compare the times at which each entry would reach the top level
+        ka := a.enqueued.Add(-time.Duration(a.priority) * h.aging)
+        kb := b.enqueued.Add(-time.Duration(b.priority) * h.aging)
+        if !ka.Equal(kb) {
+            return ka.Before(kb)
+        }
+    } else if a.priority != b.priority {
+        return a.priority > b.priority
+    }
+    return a.seq < b.seq
+}
+
+func (h *entryHeap[T]) Swap(i, j int) {
+    h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *entryHeap[T]) Push(x interface{}) {
+    h.entries = append(h.entries, x.(entry[T]))
+}
+
+func (h *entryHeap[T]) Pop() interface{} {
+    last := len(h.entries) - 1
+    e := h.entries[last]
+    h.entries[last] = entry[T]{}
+    h.entries = h.entries[:last]
+    return e
+}
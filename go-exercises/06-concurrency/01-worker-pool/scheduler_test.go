@@ -0,0 +1,180 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+)
+
+// recorder is a task function that records the order in which inputs run.
+// The input "block" waits until release is closed, to hold the only worker.
+type recorder struct {
+    mu      sync.Mutex
+    order   []string
+    started chan struct{}
+    release chan struct{}
+}
+
+func newRecorder() *recorder {
+    return &recorder{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (r *recorder) run(_ context.Context, name string) (string, error) {
+    if name == "block" {
+        close(r.started)
+        <-r.release
+        return name, nil
+    }
+    r.mu.Lock()
+    r.order = append(r.order, name)
+    r.mu.Unlock()
+    return name, nil
+}
+
+// runBacklog holds the pool's single worker, submits tasks and then waits for
+// all of them, returning the order they ran in
+func runBacklog(t *testing.T, pool *Pool[string, string], rec *recorder, submit func() []*Future[string]) []string {
+    t.Helper()
+    pool.Start()
+    defer pool.Stop()
+
+    pool.Submit("block")
+    <-rec.started
+    futures := submit()
+    close(rec.release)
+    for _, future := range futures {
+        if _, err := future.Wait(context.Background()); err != nil {
+            t.Fatalf("Wait failed: %v", err)
+        }
+    }
+    return rec.order
+}
+
+func TestPriorityOvertakesBacklog(t *testing.T) {
+    rec := newRecorder()
+    pool := NewPool(1, rec.run, WithQueueSize(100), WithAging(0))
+
+    order := runBacklog(t, pool, rec, func() []*Future[string] {
+        var futures []*Future[string]
+        for i := 0; i < 20; i++ {
+            future, _ := pool.Submit("bulk " + strconv.Itoa(i))
+            futures = append(futures, future)
+        }
+        low, _ := pool.SubmitWithPriority("low", PriorityLow)
+        urgent, _ := pool.SubmitWithPriority("urgent", PriorityHigh)
+        also, _ := pool.SubmitWithPriority("also urgent", PriorityHigh)
+        return append(futures, low, urgent, also)
+    })
+
+    if len(order) != 23 {
+        t.Fatalf("got %d tasks, want 23", len(order))
+    }
+    if order[0] != "urgent" || order[1] != "also urgent" {
+        t.Errorf("high priority tasks did not overtake the backlog: %v", order[:3])
+    }
+    for i := 0; i < 20; i++ {
+        if want := "bulk " + strconv.Itoa(i); order[i+2] != want {
+            t.Errorf("position %d: got %q, want %q in submission order", i+2, order[i+2], want)
+        }
+    }
+    if order[22] != "low" {
+        t.Errorf("low priority task ran at %v", order)
+    }
+}
+
+func TestPriorityAging(t *testing.T) {
+    tests := []struct {
+        name     string
+        waited   time.Duration
+        expected []string
+    }{
+        {"fresh tasks run by priority", 0, []string{"high", "low"}},
+        {"old task catches up", 2 * time.Second, []string{"low", "high"}},
+        {"old task overtakes", 5 * time.Second, []string{"low", "high"}},
+        {"partly aged task waits", 1500 * time.Millisecond, []string{"high", "low"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            clock := &fakeClock{}
+            rec := newRecorder()
+            pool := NewPool(1, rec.run, WithClock(clock), WithAging(time.Second))
+
+            order := runBacklog(t, pool, rec, func() []*Future[string] {
+                low, _ := pool.SubmitWithPriority("low", PriorityLow)
+                clock.Advance(tt.waited)
+                high, _ := pool.SubmitWithPriority("high", PriorityHigh)
+                return []*Future[string]{low, high}
+            })
+
+            if len(order) != 2 || order[0] != tt.expected[0] || order[1] != tt.expected[1] {
+                t.Errorf("got %v, want %v", order, tt.expected)
+            }
+        })
+    }
+}
+
+func TestPriorityLevels(t *testing.T) {
+    pool := NewPool(1, process, WithPriorityLevels(10))
+    pool.Start()
+    defer pool.Stop()
+
+    if got := pool.queue.defaultPriority(); got != 5 {
+        t.Errorf("default priority %d, want 5", got)
+    }
+    for _, priority := range []int{0, 9} {
+        future, err := pool.SubmitWithPriority(TestTask{Result: "ok"}, priority)
+        if err != nil {
+            t.Fatalf("SubmitWithPriority(%d) failed: %v", priority, err)
+        }
+        future.Wait(context.Background())
+    }
+    for _, priority := range []int{-1, 10} {
+        if _, err := pool.SubmitWithPriority(TestTask{}, priority); !errors.Is(err, ErrInvalidPriority) {
+            t.Errorf("SubmitWithPriority(%d): expected ErrInvalidPriority, got %v", priority, err)
+        }
+    }
+}
+
+func TestWorkerPoolPriority(t *testing.T) {
+    var mu sync.Mutex
+    var order []string
+    started, release := make(chan struct{}), make(chan struct{})
+    record := func(name string) Task {
+        return taskFunc(func() Result {
+            mu.Lock()
+            order = append(order, name)
+            mu.Unlock()
+            return Result{Value: name}
+        })
+    }
+
+    pool := NewWorkerPool(1, WithQueueSize(10))
+    pool.Start()
+    defer pool.Stop()
+
+    pool.Submit(taskFunc(func() Result {
+        close(started)
+        <-release
+        return Result{}
+    }))
+    <-started
+    bulk, _ := pool.Submit(record("bulk"))
+    urgent, _ := pool.SubmitWithPriority(record("urgent"), PriorityHigh)
+    close(release)
+    bulk.Wait(context.Background())
+    urgent.Wait(context.Background())
+
+    if len(order) != 2 || order[0] != "urgent" {
+        t.Errorf("got %v, want urgent first", order)
+    }
+}
+
+// taskFunc adapts a function to the Task interface
+type taskFunc func() Result
+
+func (f taskFunc) Execute() Result {
+    return f()
+}
@@ -2,7 +2,7 @@ package main
 
 import (
     "context"
-    "sync"
+    "time"
 )
 
 // Task represents a unit of work that can be executed by a worker
@@ -10,92 +10,165 @@ type Task interface {
     Execute() Result
 }
 
+// ContextTask is a unit of work that stops when its context is done
+type ContextTask interface {
+    ExecuteContext(ctx context.Context) Result
+}
+
+// AdaptTask turns a Task into a ContextTask. Execute runs on its own goroutine,
+// so a hung task frees its worker when the context is done, although the
+// goroutine keeps running until Execute returns. A panic in Execute is
+// re-raised on the worker.
+func AdaptTask(task Task) ContextTask {
+    if ct, ok := task.(ContextTask); ok {
+        return ct
+    }
+    return legacyTask{task}
+}
+
+type legacyTask struct {
+    task Task
+}
+
+func (t legacyTask) ExecuteContext(ctx context.Context) Result {
+    done := make(chan Result, 1)
+    panicked := make(chan *PanicError, 1)
+    go func() {
+        defer recoverTask(panicked)
+        done <- t.task.Execute()
+    }()
+    select {
+    case result := <-done:
+        return result
+    case panicErr := <-panicked:
+        panic(panicErr)
+    case <-ctx.Done():
+        return Result{Error: ctx.Err()}
+    }
+}
+
 // Result represents the result of a task execution
 type Result struct {
-    Value interface{}
-    Error error
+    ID       uint64
+    Value    interface{}
+    Error    error
+    Attempts int
+    Errors   []error // the error of every failed attempt
 }
 
-// WorkerPool represents a pool of worker goroutines
+// WorkerPool runs Tasks on a pool of worker goroutines. It adapts the Task and
+// Result API to a Pool[ContextTask, interface{}]; new code can use Pool directly
+// to get typed results.
 type WorkerPool struct {
-    numWorkers int
-    tasks      chan Task
-    results    chan Result
-    done       chan struct{}
-    wg         sync.WaitGroup
-    ctx        context.Context
-    cancel     context.CancelFunc
-}
-
-// NewWorkerPool creates a new worker pool with the specified number of workers
-func NewWorkerPool(numWorkers int) *WorkerPool {
-    ctx, cancel := context.WithCancel(context.Background())
+    numWorkers int // initial size; see Workers
+    pool       *Pool[ContextTask, interface{}]
+}
+
+// NewWorkerPool creates a new worker pool with the specified number of workers.
+// Its results stream holds as many results as the queue unless opts include
+// WithResultStream.
+func NewWorkerPool(numWorkers int, opts ...PoolOption) *WorkerPool {
+    opts = append([]PoolOption{WithResultStream(0)}, opts...)
     return &WorkerPool{
         numWorkers: numWorkers,
-        tasks:      make(chan Task, numWorkers*2), // Buffered channel
-        results:    make(chan Result, numWorkers*2), // Buffered channel
-        done:       make(chan struct{}),
-        ctx:        ctx,
-        cancel:     cancel,
+        pool: NewPool(numWorkers, func(ctx context.Context, task ContextTask) (interface{}, error) {
+            result := task.ExecuteContext(ctx)
+            return result.Value, result.Error
+        }, opts...),
     }
 }
 
 // Start starts the worker pool
 func (p *WorkerPool) Start() {
-    for i := 0; i < p.numWorkers; i++ {
-        p.wg.Add(1)
-        go p.worker(i)
-    }
+    p.pool.Start()
 }
 
 // Stop stops the worker pool gracefully
 func (p *WorkerPool) Stop() {
-    p.cancel()
-    close(p.done)
-    p.wg.Wait()
-    close(p.tasks)
-    close(p.results)
+    p.pool.Stop()
 }
 
-// Submit submits a task to the worker pool
-func (p *WorkerPool) Submit(task Task) error {
-    select {
-    case p.tasks <- task:
-        return nil
-    case <-p.ctx.Done():
-        return p.ctx.Err()
+// Workers returns the number of workers the pool runs
+func (p *WorkerPool) Workers() int {
+    return p.pool.Workers()
+}
+
+// Restarts returns how many times a worker died from a panic and was restarted
+func (p *WorkerPool) Restarts() uint64 {
+    return p.pool.Restarts()
+}
+
+// Resize changes the number of workers without dropping in-flight tasks
+func (p *WorkerPool) Resize(n int) error {
+    return p.pool.Resize(n)
+}
+
+// Submit submits a task to the worker pool and returns a handle for its result.
+// Tasks that also implement ContextTask are run through ExecuteContext.
+func (p *WorkerPool) Submit(task Task) (*TaskHandle, error) {
+    return p.SubmitContext(AdaptTask(task), 0)
+}
+
+// SubmitWithPriority submits a task at the given priority; higher priorities run first
+func (p *WorkerPool) SubmitWithPriority(task Task, priority int) (*TaskHandle, error) {
+    future, err := p.pool.SubmitWithPriority(AdaptTask(task), priority)
+    if err != nil {
+        return nil, err
     }
+    return &TaskHandle{future: future}, nil
 }
 
-// GetResult retrieves a result from the worker pool
+// SubmitContext submits a context-aware task. Its context is cancelled when the
+// pool stops or, for a non-zero timeout, once the task has run that long; a
+// task failing after its deadline gets a *TimeoutError.
+func (p *WorkerPool) SubmitContext(task ContextTask, timeout time.Duration) (*TaskHandle, error) {
+    future, err := p.pool.SubmitWithTimeout(task, timeout)
+    if err != nil {
+        return nil, err
+    }
+    return &TaskHandle{future: future}, nil
+}
+
+// GetResult retrieves the next result that was not waited on through its handle
 func (p *WorkerPool) GetResult() (Result, error) {
-    select {
-    case result := <-p.results:
-        return result, nil
-    case <-p.ctx.Done():
-        return Result{}, p.ctx.Err()
+    result, err := p.pool.GetResult()
+    if err != nil {
+        return Result{}, err
+    }
+    return toResult(result), nil
+}
+
+// TaskHandle is the WorkerPool counterpart of Future
+type TaskHandle struct {
+    future *Future[interface{}]
+}
+
+// ID returns the task ID, which is also set on the task's Result
+func (h *TaskHandle) ID() uint64 {
+    return h.future.ID()
+}
+
+// Done returns a channel that is closed once the result is available
+func (h *TaskHandle) Done() <-chan struct{} {
+    return h.future.Done()
+}
+
+// Wait blocks until the task finishes, ctx is done or the pool is stopped
+func (h *TaskHandle) Wait(ctx context.Context) (Result, error) {
+    result, err := h.future.Wait(ctx)
+    if err != nil {
+        return Result{}, err
     }
+    return toResult(result), nil
 }
 
-// worker processes tasks from the tasks channel
-func (p *WorkerPool) worker(id int) {
-    defer p.wg.Done()
-
-    for {
-        select {
-        case task, ok := <-p.tasks:
-            if !ok {
-                return
-            }
-            result := task.Execute()
-            select {
-            case p.results <- result:
-            case <-p.ctx.Done():
-                return
-            }
-        case <-p.ctx.Done():
-            return
-        }
+func toResult(result TypedResult[interface{}]) Result {
+    return Result{
+        ID:       result.ID,
+        Value:    result.Value,
+        Error:    result.Error,
+        Attempts: result.Attempts,
+        Errors:   result.Errors,
     }
 }
 
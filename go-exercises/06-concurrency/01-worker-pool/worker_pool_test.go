@@ -1,7 +1,9 @@
 package main
 
 import (
+    "context"
     "errors"
+    "strconv"
     "sync"
     "testing"
     "time"
@@ -48,7 +50,7 @@ func TestWorkerPoolBasic(t *testing.T) {
     }
 
     for _, task := range tasks {
-        err := pool.Submit(task)
+        _, err := pool.Submit(task)
         if err != nil {
             t.Errorf("Failed to submit task %d: %v", task.ID, err)
         }
@@ -83,10 +85,10 @@ func TestWorkerPoolConcurrent(t *testing.T) {
             defer wg.Done()
             task := TestTask{
                 ID:       id,
-                Result:   "Task " + string(id),
+                Result:   "Task " + strconv.Itoa(id),
                 Duration: 50 * time.Millisecond,
             }
-            err := pool.Submit(task)
+            _, err := pool.Submit(task)
             if err != nil {
                 t.Errorf("Failed to submit task %d: %v", id, err)
                 return
@@ -128,7 +130,7 @@ func TestWorkerPoolErrorHandling(t *testing.T) {
         Result: "Error Task",
         Error:  errors.New("task error"),
     }
-    err := pool.Submit(task)
+    _, err := pool.Submit(task)
     if err != nil {
         t.Errorf("Failed to submit task: %v", err)
     }
@@ -154,10 +156,10 @@ func TestWorkerPoolGracefulShutdown(t *testing.T) {
     for i := 0; i < 5; i++ {
         task := TestTask{
             ID:       i,
-            Result:   "Task " + string(i),
+            Result:   "Task " + strconv.Itoa(i),
             Duration: 100 * time.Millisecond,
         }
-        err := pool.Submit(task)
+        _, err := pool.Submit(task)
         if err != nil {
             t.Errorf("Failed to submit task %d: %v", i, err)
         }
@@ -167,14 +169,75 @@ func TestWorkerPoolGracefulShutdown(t *testing.T) {
     pool.Stop()
 
     // Try to submit a task after stopping
-    err := pool.Submit(TestTask{ID: 6, Result: "Task 6"})
+    _, err := pool.Submit(TestTask{ID: 6, Result: "Task 6"})
     if err == nil {
         t.Error("Expected error when submitting task after stopping, got nil")
     }
 
     // Try to get results after stopping
-    result, err := pool.GetResult()
+    _, err = pool.GetResult()
     if err == nil {
         t.Error("Expected error when getting result after stopping, got nil")
     }
-} 
\ No newline at end of file
+}
+
+// SleepTask waits for its duration or until its context is done
+type SleepTask struct {
+    Duration time.Duration
+}
+
+func (t SleepTask) Execute() Result {
+    return t.ExecuteContext(context.Background())
+}
+
+func (t SleepTask) ExecuteContext(ctx context.Context) Result {
+    select {
+    case <-time.After(t.Duration):
+        return Result{Value: "slept"}
+    case <-ctx.Done():
+        return Result{Error: ctx.Err()}
+    }
+}
+
+func TestWorkerPoolContextTasks(t *testing.T) {
+    pool := NewWorkerPool(1)
+    pool.Start()
+    defer pool.Stop()
+
+    // Submit uses ExecuteContext when a task provides it
+    handle, _ := pool.Submit(SleepTask{Duration: time.Millisecond})
+    if result, err := handle.Wait(context.Background()); err != nil || result.Value != "slept" {
+        t.Errorf("Submit(SleepTask) = %+v, %v", result, err)
+    }
+
+    handle, _ = pool.SubmitContext(SleepTask{Duration: time.Hour}, 20*time.Millisecond)
+    result, err := handle.Wait(context.Background())
+    if err != nil {
+        t.Fatalf("Wait failed: %v", err)
+    }
+    var timeoutErr *TimeoutError
+    if !errors.As(result.Error, &timeoutErr) || !errors.Is(result.Error, context.DeadlineExceeded) {
+        t.Errorf("expected *TimeoutError, got %v", result.Error)
+    }
+}
+
+func TestWorkerPoolLegacyTimeout(t *testing.T) {
+    pool := NewWorkerPool(1)
+    pool.Start()
+    defer pool.Stop()
+
+    // a legacy task ignores its context, but the adapter frees the worker
+    hung := TestTask{Result: "late", Duration: time.Second}
+    handle, _ := pool.SubmitContext(AdaptTask(hung), 20*time.Millisecond)
+    next, _ := pool.Submit(TestTask{Result: "next"})
+
+    ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+    defer cancel()
+    result, err := handle.Wait(ctx)
+    if err != nil || !errors.Is(result.Error, context.DeadlineExceeded) {
+        t.Errorf("hung task = %+v, %v; want a timeout", result, err)
+    }
+    if result, err := next.Wait(ctx); err != nil || result.Value != "next" {
+        t.Errorf("next task = %+v, %v; the worker is still pinned", result, err)
+    }
+}